@@ -0,0 +1,200 @@
+package tilda_go
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BulkOptions allows to customize bulk requests
+type BulkOptions struct {
+	Concurrency int  // Max number of simultaneous requests (1 if not set)
+	Full        bool // Use GetPageFullExport instead of GetPageExport
+}
+
+// PageError represents error of fetching single page in bulk request
+type PageError struct {
+	PageID string // ID of page that was not fetched
+	Err    error  // Error
+}
+
+// Error() converts error to string
+func (e *PageError) Error() string {
+	return fmt.Sprintf("page %s: %s", e.PageID, e.Err.Error())
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// BulkError aggregates errors of pages that were not fetched in bulk request
+type BulkError struct {
+	Errors []*PageError
+}
+
+// Error() converts error to string
+func (e *BulkError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("%d pages failed: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// GetProjectPagesExport returns export information of all project pages sorted by Sort.
+// Pages are fetched concurrently; failed pages are skipped and reported in *BulkError,
+// so the result contains every page that was fetched successfully.
+// Each request goes through the same path as single requests, so client rate limiting applies to them too.
+func (c *Client) GetProjectPagesExport(ctx context.Context, projectID string, opts BulkOptions) ([]PageExport, error) {
+	pages, err := c.GetProjectPages(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("get project pages: %w", err)
+	}
+
+	return c.GetPagesExport(ctx, pages, opts)
+}
+
+// GetPagesExport returns export information of the pages (e.g. changed pages from GetProjectPages listing) sorted by Sort.
+// Pages are fetched concurrently; failed pages are skipped and reported in *BulkError.
+func (c *Client) GetPagesExport(ctx context.Context, pages []Page, opts BulkOptions) ([]PageExport, error) {
+	sorted := make([]Page, len(pages))
+	copy(sorted, pages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Sort < sorted[j].Sort
+	})
+
+	getPage := c.GetPageExport
+	if opts.Full {
+		getPage = c.GetPageFullExport
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]PageExport, len(sorted))
+	errs := make([]error, len(sorted))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, page := range sorted {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, pageID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i], errs[i] = getPage(ctx, pageID)
+		}(i, page.ID)
+	}
+	wg.Wait()
+
+	var bulkErr BulkError
+	exports := make([]PageExport, 0, len(sorted))
+	for i, page := range sorted {
+		if errs[i] != nil {
+			bulkErr.Errors = append(bulkErr.Errors, &PageError{PageID: page.ID, Err: errs[i]})
+			continue
+		}
+
+		exports = append(exports, results[i])
+	}
+
+	if len(bulkErr.Errors) > 0 {
+		return exports, &bulkErr
+	}
+
+	return exports, nil
+}
+
+// fetchEach fetches pages with bounded concurrency and passes every result to fn as soon as it arrives.
+// fn is never called concurrently. Failed pages are returned as *PageError; fetching stops when fn returns an error.
+func fetchEach[T any](
+	ctx context.Context,
+	pages []Page,
+	concurrency int,
+	fetch func(context.Context, Page) (T, error),
+	fn func(Page, T) error,
+) ([]*PageError, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		page  Page
+		value T
+		err   error
+	}
+
+	results := make(chan result)
+	go func() {
+		defer close(results)
+
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for _, page := range pages {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			case sem <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func(page Page) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				value, err := fetch(ctx, page)
+				select {
+				case results <- result{page: page, value: value, err: err}:
+				case <-ctx.Done():
+				}
+			}(page)
+		}
+		wg.Wait()
+	}()
+
+	var pageErrs []*PageError
+	for r := range results {
+		if r.err != nil {
+			pageErrs = append(pageErrs, &PageError{PageID: r.page.ID, Err: r.err})
+			continue
+		}
+
+		if err := fn(r.page, r.value); err != nil {
+			cancel()
+			for range results {
+			}
+
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return pageErrs, nil
+}
@@ -0,0 +1,212 @@
+package tilda_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var regexpPageExport = regexp.MustCompile(`/v1/getpageexport/`)
+
+func registerPagesList(projectID string, pages []Page) {
+	url := fmt.Sprintf("%s/v1/getpageslist/?projectid=%s&publickey=public&secretkey=secret", apiBaseUrl, projectID)
+	httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(pages)))
+}
+
+func registerPageExport(endpoint string, page PageExport) {
+	url := fmt.Sprintf("%s/v1/%s/?pageid=%s&publickey=public&secretkey=secret", apiBaseUrl, endpoint, page.ID)
+	httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(page)))
+}
+
+func TestClient_GetProjectPagesExport(t *testing.T) {
+	listing := []Page{
+		{ID: "3", ProjectID: "54321", Sort: 30},
+		{ID: "1", ProjectID: "54321", Sort: 10},
+		{ID: "2", ProjectID: "54321", Sort: 20},
+	}
+
+	tests := []struct {
+		name              string
+		opts              BulkOptions
+		registerResponder func()
+		want              []PageExport
+		wantFailed        []string
+		wantErr           bool
+	}{
+		{
+			name: "success",
+			opts: BulkOptions{Concurrency: 2},
+			registerResponder: func() {
+				registerPagesList("54321", listing)
+				for _, page := range listing {
+					registerPageExport("getpageexport", PageExport{ID: page.ID, Sort: page.Sort, HTML: "body"})
+				}
+			},
+			want: []PageExport{
+				{ID: "1", Sort: 10, HTML: "body"},
+				{ID: "2", Sort: 20, HTML: "body"},
+				{ID: "3", Sort: 30, HTML: "body"},
+			},
+		}, {
+			name: "full",
+			opts: BulkOptions{Full: true},
+			registerResponder: func() {
+				registerPagesList("54321", listing)
+				for _, page := range listing {
+					registerPageExport("getpagefullexport", PageExport{ID: page.ID, Sort: page.Sort, HTML: "<html></html>"})
+				}
+			},
+			want: []PageExport{
+				{ID: "1", Sort: 10, HTML: "<html></html>"},
+				{ID: "2", Sort: 20, HTML: "<html></html>"},
+				{ID: "3", Sort: 30, HTML: "<html></html>"},
+			},
+		}, {
+			name: "partial failure",
+			opts: BulkOptions{Concurrency: 3},
+			registerResponder: func() {
+				registerPagesList("54321", listing)
+				registerPageExport("getpageexport", PageExport{ID: "1", Sort: 10})
+				registerPageExport("getpageexport", PageExport{ID: "3", Sort: 30})
+				httpmock.RegisterResponder(http.MethodGet,
+					fmt.Sprintf("%s/v1/getpageexport/?pageid=2&publickey=public&secretkey=secret", apiBaseUrl),
+					httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+			},
+			want: []PageExport{
+				{ID: "1", Sort: 10},
+				{ID: "3", Sort: 30},
+			},
+			wantFailed: []string{"2"},
+			wantErr:    true,
+		}, {
+			name: "list failed",
+			registerResponder: func() {
+				httpmock.RegisterResponder(http.MethodGet,
+					fmt.Sprintf("%s/v1/getpageslist/?projectid=54321&publickey=public&secretkey=secret", apiBaseUrl),
+					httpmock.NewStringResponder(http.StatusOK, "{"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			tt.registerResponder()
+
+			c := NewClient(&Config{
+				PublicKey: "public",
+				SecretKey: "secret",
+			})
+			got, err := c.GetProjectPagesExport(context.Background(), "54321", tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var bulkErr *BulkError
+			if len(tt.wantFailed) > 0 && assert.ErrorAs(t, err, &bulkErr) {
+				var failed []string
+				for _, pageErr := range bulkErr.Errors {
+					failed = append(failed, pageErr.PageID)
+				}
+				assert.Equal(t, tt.wantFailed, failed)
+
+				var tildaErr *TildaError
+				assert.ErrorAs(t, err, &tildaErr)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_GetProjectPagesExport_Concurrency(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var listing []Page
+	for i := range 10 {
+		listing = append(listing, Page{ID: fmt.Sprintf("%d", i+1), Sort: i})
+	}
+	registerPagesList("54321", listing)
+
+	var active, maxActive int32
+	httpmock.RegisterRegexpResponder(http.MethodGet, regexpPageExport,
+		func(req *http.Request) (*http.Response, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			return httpmock.NewJsonResponse(http.StatusOK, NewResponse(PageExport{ID: req.URL.Query().Get("pageid")}))
+		},
+	)
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+	got, err := c.GetProjectPagesExport(context.Background(), "54321", BulkOptions{Concurrency: 3})
+	assert.NoError(t, err)
+	assert.Len(t, got, 10)
+	assert.LessOrEqual(t, maxActive, int32(3))
+	for i, page := range got {
+		assert.Equal(t, listing[i].ID, page.ID)
+	}
+}
+
+func TestClient_GetProjectPagesExport_Canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerPagesList("54321", []Page{{ID: "1"}, {ID: "2"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	httpmock.RegisterRegexpResponder(http.MethodGet, regexpPageExport,
+		func(req *http.Request) (*http.Response, error) {
+			cancel()
+
+			return nil, context.Canceled
+		},
+	)
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+	got, err := c.GetProjectPagesExport(ctx, "54321", BulkOptions{})
+	assert.Empty(t, got)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestClient_GetPagesExport(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerPageExport("getpagefullexport", PageExport{ID: "2", Sort: 20, HTML: "full"})
+	registerPageExport("getpagefullexport", PageExport{ID: "1", Sort: 10, HTML: "full"})
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+	got, err := c.GetPagesExport(context.Background(), []Page{{ID: "2", Sort: 20}, {ID: "1", Sort: 10}}, BulkOptions{Full: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []PageExport{{ID: "1", Sort: 10, HTML: "full"}, {ID: "2", Sort: 20, HTML: "full"}}, got)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,173 @@
+package tilda_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Checkpoint records pages completed by bulk job in the file, so the job can be resumed after restart
+// without fetching these pages again
+type Checkpoint struct {
+	path  string
+	mu    sync.Mutex
+	pages map[string]int
+}
+
+type checkpointFile struct {
+	Pages map[string]int `json:"pages"` // Published value of completed pages by page ID
+}
+
+// OpenCheckpoint loads checkpoint from the file or creates empty one if the file doesn't exist yet
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		path:  path,
+		pages: make(map[string]int),
+	}
+
+	bts, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(bts, &file); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+
+	for pageID, published := range file.Pages {
+		checkpoint.pages[pageID] = published
+	}
+
+	return checkpoint, nil
+}
+
+// Done reports whether the page was completed and hasn't been published again since then
+func (c *Checkpoint) Done(page Page) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	published, ok := c.pages[page.ID]
+
+	return ok && published == page.Published
+}
+
+// MarkDone records the page as completed and saves the checkpoint file
+func (c *Checkpoint) MarkDone(pageID string, published int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pages[pageID] = published
+
+	return c.save()
+}
+
+// PageIDs returns sorted IDs of completed pages
+func (c *Checkpoint) PageIDs() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.pages))
+	for pageID := range c.pages {
+		ids = append(ids, pageID)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (c *Checkpoint) save() error {
+	bts, err := json.Marshal(checkpointFile{Pages: c.pages})
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(bts); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("rename checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// ForEachProjectPageExport fetches export information of project pages and calls fn for every page as soon as it is fetched.
+// If checkpoint is not nil, pages completed in previous runs with the same Published value are skipped
+// and every page is marked as completed after fn returns without error, so interrupted job can be resumed.
+// fn is never called concurrently. Failed pages are skipped and reported in *BulkError.
+func (c *Client) ForEachProjectPageExport(
+	ctx context.Context,
+	projectID string,
+	checkpoint *Checkpoint,
+	opts BulkOptions,
+	fn func(PageExport) error,
+) error {
+	pages, err := c.GetProjectPages(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("get project pages: %w", err)
+	}
+
+	pending := make([]Page, 0, len(pages))
+	for _, page := range pages {
+		if checkpoint != nil && checkpoint.Done(page) {
+			continue
+		}
+
+		pending = append(pending, page)
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Sort < pending[j].Sort
+	})
+
+	getPage := c.GetPageExport
+	if opts.Full {
+		getPage = c.GetPageFullExport
+	}
+
+	pageErrs, err := fetchEach(ctx, pending, opts.Concurrency, func(ctx context.Context, page Page) (PageExport, error) {
+		return getPage(ctx, page.ID)
+	}, func(page Page, export PageExport) error {
+		if err := fn(export); err != nil {
+			return err
+		}
+
+		if checkpoint != nil {
+			if err := checkpoint.MarkDone(page.ID, page.Published); err != nil {
+				return fmt.Errorf("mark page %s done: %w", page.ID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(pageErrs) > 0 {
+		return &BulkError{Errors: pageErrs}
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package tilda_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenCheckpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "not exists",
+			want: []string{},
+		}, {
+			name:    "exists",
+			content: `{"pages":{"2":200,"1":100}}`,
+			want:    []string{"1", "2"},
+		}, {
+			name:    "invalid",
+			content: `{`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+			if tt.content != "" {
+				assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			}
+
+			checkpoint, err := OpenCheckpoint(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, checkpoint.PageIDs())
+		})
+	}
+}
+
+func TestCheckpoint_MarkDone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	checkpoint, err := OpenCheckpoint(path)
+	assert.NoError(t, err)
+	assert.False(t, checkpoint.Done(Page{ID: "1", Published: 100}))
+
+	assert.NoError(t, checkpoint.MarkDone("1", 100))
+	assert.True(t, checkpoint.Done(Page{ID: "1", Published: 100}))
+	assert.False(t, checkpoint.Done(Page{ID: "1", Published: 101}))
+
+	reopened, err := OpenCheckpoint(path)
+	assert.NoError(t, err)
+	assert.True(t, reopened.Done(Page{ID: "1", Published: 100}))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestClient_ForEachProjectPageExport(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	listing := []Page{
+		{ID: "1", Sort: 10, Published: 100},
+		{ID: "2", Sort: 20, Published: 200},
+		{ID: "3", Sort: 30, Published: 300},
+	}
+	registerPagesList("54321", listing)
+	for _, page := range listing {
+		registerPageExport("getpageexport", PageExport{ID: page.ID, Published: page.Published})
+	}
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+
+	checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+	assert.NoError(t, err)
+
+	errInterrupted := errors.New("interrupted")
+	var stored []string
+	err = c.ForEachProjectPageExport(context.Background(), "54321", checkpoint, BulkOptions{}, func(page PageExport) error {
+		if page.ID == "2" {
+			return errInterrupted
+		}
+
+		stored = append(stored, page.ID)
+
+		return nil
+	})
+	assert.ErrorIs(t, err, errInterrupted)
+	assert.Equal(t, []string{"1"}, stored)
+	assert.Equal(t, []string{"1"}, checkpoint.PageIDs())
+
+	httpmock.ZeroCallCounters()
+	err = c.ForEachProjectPageExport(context.Background(), "54321", checkpoint, BulkOptions{Concurrency: 2}, func(page PageExport) error {
+		stored = append(stored, page.ID)
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, stored)
+	assert.Equal(t, []string{"1", "2", "3"}, checkpoint.PageIDs())
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 0, calls[fmt.Sprintf("GET %s/v1/getpageexport/?pageid=1&publickey=public&secretkey=secret", apiBaseUrl)])
+	assert.Equal(t, 1, calls[fmt.Sprintf("GET %s/v1/getpageexport/?pageid=2&publickey=public&secretkey=secret", apiBaseUrl)])
+	assert.Equal(t, 1, calls[fmt.Sprintf("GET %s/v1/getpageexport/?pageid=3&publickey=public&secretkey=secret", apiBaseUrl)])
+
+	registerPagesList("54321", []Page{
+		{ID: "1", Sort: 10, Published: 150},
+		{ID: "2", Sort: 20, Published: 200},
+		{ID: "3", Sort: 30, Published: 300},
+	})
+	httpmock.RegisterResponder(http.MethodGet,
+		fmt.Sprintf("%s/v1/getpageexport/?pageid=1&publickey=public&secretkey=secret", apiBaseUrl),
+		httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+
+	stored = nil
+	err = c.ForEachProjectPageExport(context.Background(), "54321", checkpoint, BulkOptions{}, func(page PageExport) error {
+		stored = append(stored, page.ID)
+
+		return nil
+	})
+
+	var bulkErr *BulkError
+	if assert.ErrorAs(t, err, &bulkErr) {
+		assert.Len(t, bulkErr.Errors, 1)
+		assert.Equal(t, "1", bulkErr.Errors[0].PageID)
+	}
+	assert.Empty(t, stored)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
 const apiBaseUrl string = "https://api.tildacdn.info"
@@ -33,6 +34,7 @@ type Client struct {
 	config     *Config
 	httpClient *http.Client
 	baseURL    string
+	limiter    *rateLimiter
 }
 
 // NewClient creates new Tilda client
@@ -64,9 +66,21 @@ func WithCustomHttpClient(httpClient *http.Client) func(*Client) {
 	}
 }
 
+// WithRateLimit option allows to limit the number of requests per interval (Tilda allows 150 requests per hour).
+// Requests over the limit wait for the next interval.
+func WithRateLimit(limit int, interval time.Duration) func(*Client) {
+	return func(s *Client) {
+		s.limiter = newRateLimiter(limit, interval)
+	}
+}
+
 func (c *Client) doRequest(ctx context.Context, path string, params map[string]any, result any) error {
 	url := c.baseURL + path
 
+	if err := c.limiter.wait(ctx); err != nil {
+		return fmt.Errorf("wait rate limit: %w", err)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
@@ -107,7 +121,7 @@ func (c *Client) doRequest(ctx context.Context, path string, params map[string]a
 		return &TildaError{resp.StatusCode, url, string(body), fmt.Errorf("unmarshal response: %w", err)}
 	}
 
-	if responseCheck.Status != "FOUND" {
+	if responseCheck.Status != StatusFound {
 		return &TildaError{resp.StatusCode, url, string(body), errors.New("invalid status in response, expected FOUND")}
 	}
 
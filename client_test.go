@@ -271,6 +271,30 @@ func TestNewClient(t *testing.T) {
 				},
 				baseURL: apiBaseUrl,
 			},
+		}, {
+			name: "with rate limit",
+			args: args{
+				config: &Config{
+					PublicKey: "public",
+					SecretKey: "secret",
+				},
+				options: []func(*Client){
+					WithRateLimit(150, time.Hour),
+				},
+			},
+			want: &Client{
+				config: &Config{
+					PublicKey: "public",
+					SecretKey: "secret",
+				},
+				httpClient: http.DefaultClient,
+				baseURL:    apiBaseUrl,
+				limiter: &rateLimiter{
+					clock:    realClock{},
+					limit:    150,
+					interval: time.Hour,
+				},
+			},
 		},
 	}
 	for _, tt := range tests {
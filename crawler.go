@@ -0,0 +1,169 @@
+package tilda_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PageVariant selects the endpoint used to get page details
+type PageVariant int
+
+const (
+	PageVariantBody       PageVariant = iota // GetPage
+	PageVariantFull                          // GetPageFull
+	PageVariantExport                        // GetPageExport
+	PageVariantFullExport                    // GetPageFullExport
+)
+
+// CrawlOptions allows to customize crawling of the account
+type CrawlOptions struct {
+	ProjectIDs     []string    // Crawl only these projects (all projects of the account if empty)
+	PublishedAfter time.Time   // Skip pages published before this moment
+	Variant        PageVariant // Endpoint used to get page details
+	Concurrency    int         // Max number of simultaneous page requests (1 if not set)
+}
+
+// CrawledPage represents page found by crawler
+type CrawledPage struct {
+	Project Project     // Project information
+	Page    Page        // Page from the project pages list, with body HTML code and assets for PageVariantBody
+	Full    *PageFull   // Page with full HTML code, set for PageVariantFull
+	Export  *PageExport // Page for export, set for PageVariantExport and PageVariantFullExport
+}
+
+// ProjectError represents error of crawling single project
+type ProjectError struct {
+	ProjectID string // ID of project that was not crawled
+	Err       error  // Error
+}
+
+// Error() converts error to string
+func (e *ProjectError) Error() string {
+	return fmt.Sprintf("project %s: %s", e.ProjectID, e.Err.Error())
+}
+
+func (e *ProjectError) Unwrap() error {
+	return e.Err
+}
+
+// Crawl walks all projects of the account and their pages and calls fn for every page as soon as it is fetched.
+// fn is never called concurrently. Crawling stops when fn returns an error; failures of single projects
+// and pages don't stop crawling and are returned joined as *ProjectError and *PageError after all pages are visited.
+func (c *Client) Crawl(ctx context.Context, opts CrawlOptions, fn func(CrawledPage) error) error {
+	projectIDs := opts.ProjectIDs
+	if len(projectIDs) == 0 {
+		projects, err := c.GetProjectsList(ctx)
+		if err != nil {
+			return fmt.Errorf("get projects list: %w", err)
+		}
+
+		for _, project := range projects {
+			projectIDs = append(projectIDs, project.ID)
+		}
+	}
+
+	var errs []error
+	for _, projectID := range projectIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		projectErrs, err := c.crawlProject(ctx, projectID, opts, fn)
+		if err != nil {
+			return err
+		}
+
+		errs = append(errs, projectErrs...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Client) crawlProject(ctx context.Context, projectID string, opts CrawlOptions, fn func(CrawledPage) error) ([]error, error) {
+	project, err := c.GetProjectInfo(ctx, projectID)
+	if err != nil {
+		return []error{&ProjectError{ProjectID: projectID, Err: fmt.Errorf("get project info: %w", err)}}, nil
+	}
+
+	pages, err := c.GetProjectPages(ctx, projectID)
+	if err != nil {
+		return []error{&ProjectError{ProjectID: projectID, Err: fmt.Errorf("get project pages: %w", err)}}, nil
+	}
+
+	pages = filterPublishedAfter(pages, opts.PublishedAfter)
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Sort < pages[j].Sort
+	})
+
+	pageErrs, err := fetchEach(ctx, pages, opts.Concurrency, func(ctx context.Context, page Page) (CrawledPage, error) {
+		return c.getCrawledPage(ctx, project, page, opts.Variant)
+	}, func(_ Page, crawled CrawledPage) error {
+		return fn(crawled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, 0, len(pageErrs))
+	for _, pageErr := range pageErrs {
+		errs = append(errs, pageErr)
+	}
+
+	return errs, nil
+}
+
+func (c *Client) getCrawledPage(ctx context.Context, project Project, page Page, variant PageVariant) (CrawledPage, error) {
+	crawled := CrawledPage{
+		Project: project,
+		Page:    page,
+	}
+
+	switch variant {
+	case PageVariantBody:
+		body, err := c.GetPage(ctx, page.ID)
+		if err != nil {
+			return CrawledPage{}, err
+		}
+
+		crawled.Page = body
+	case PageVariantFull:
+		full, err := c.GetPageFull(ctx, page.ID)
+		if err != nil {
+			return CrawledPage{}, err
+		}
+
+		crawled.Full = &full
+	case PageVariantExport, PageVariantFullExport:
+		getPage := c.GetPageExport
+		if variant == PageVariantFullExport {
+			getPage = c.GetPageFullExport
+		}
+
+		export, err := getPage(ctx, page.ID)
+		if err != nil {
+			return CrawledPage{}, err
+		}
+
+		crawled.Export = &export
+	default:
+		return CrawledPage{}, fmt.Errorf("unknown page variant %d", variant)
+	}
+
+	return crawled, nil
+}
+
+func filterPublishedAfter(pages []Page, after time.Time) []Page {
+	filtered := make([]Page, 0, len(pages))
+	for _, page := range pages {
+		if !after.IsZero() && int64(page.Published) < after.Unix() {
+			continue
+		}
+
+		filtered = append(filtered, page)
+	}
+
+	return filtered
+}
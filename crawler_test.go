@@ -0,0 +1,173 @@
+package tilda_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func registerCrawlAccount() {
+	httpmock.RegisterResponder(http.MethodGet,
+		fmt.Sprintf("%s/v1/getprojectslist/?publickey=public&secretkey=secret", apiBaseUrl),
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse([]Project{{ID: "1"}, {ID: "2"}})))
+
+	for _, projectID := range []string{"1", "2"} {
+		httpmock.RegisterResponder(http.MethodGet,
+			fmt.Sprintf("%s/v1/getprojectinfo/?projectid=%s&publickey=public&secretkey=secret", apiBaseUrl, projectID),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(Project{ID: projectID, Title: "Project " + projectID})))
+	}
+
+	registerPagesList("1", []Page{
+		{ID: "12", ProjectID: "1", Sort: 20, Published: 200},
+		{ID: "11", ProjectID: "1", Sort: 10, Published: 100},
+	})
+	registerPagesList("2", []Page{
+		{ID: "21", ProjectID: "2", Sort: 10, Published: 300},
+	})
+
+	for _, pageID := range []string{"11", "12", "21"} {
+		httpmock.RegisterResponder(http.MethodGet,
+			fmt.Sprintf("%s/v1/getpage/?pageid=%s&publickey=public&secretkey=secret", apiBaseUrl, pageID),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(Page{ID: pageID, HTML: "body " + pageID})))
+		httpmock.RegisterResponder(http.MethodGet,
+			fmt.Sprintf("%s/v1/getpagefull/?pageid=%s&publickey=public&secretkey=secret", apiBaseUrl, pageID),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(PageFull{ID: pageID, HTML: "full " + pageID})))
+		registerPageExport("getpageexport", PageExport{ID: pageID, HTML: "export " + pageID})
+		registerPageExport("getpagefullexport", PageExport{ID: pageID, HTML: "full export " + pageID})
+	}
+}
+
+func TestClient_Crawl(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              CrawlOptions
+		registerResponder func()
+		wantHTML          []string
+		wantErr           bool
+	}{
+		{
+			name:              "all",
+			registerResponder: registerCrawlAccount,
+			wantHTML:          []string{"body 11", "body 12", "body 21"},
+		}, {
+			name: "project ids",
+			opts: CrawlOptions{
+				ProjectIDs:  []string{"2"},
+				Variant:     PageVariantFull,
+				Concurrency: 2,
+			},
+			registerResponder: registerCrawlAccount,
+			wantHTML:          []string{"full 21"},
+		}, {
+			name: "published after",
+			opts: CrawlOptions{
+				PublishedAfter: time.Unix(200, 0),
+				Variant:        PageVariantExport,
+			},
+			registerResponder: registerCrawlAccount,
+			wantHTML:          []string{"export 12", "export 21"},
+		}, {
+			name: "full export",
+			opts: CrawlOptions{
+				ProjectIDs: []string{"1"},
+				Variant:    PageVariantFullExport,
+			},
+			registerResponder: registerCrawlAccount,
+			wantHTML:          []string{"full export 11", "full export 12"},
+		}, {
+			name: "page failed",
+			opts: CrawlOptions{
+				ProjectIDs: []string{"1", "2"},
+			},
+			registerResponder: func() {
+				registerCrawlAccount()
+				httpmock.RegisterResponder(http.MethodGet,
+					fmt.Sprintf("%s/v1/getpage/?pageid=11&publickey=public&secretkey=secret", apiBaseUrl),
+					httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+			},
+			wantHTML: []string{"body 12", "body 21"},
+			wantErr:  true,
+		}, {
+			name: "project failed",
+			registerResponder: func() {
+				registerCrawlAccount()
+				httpmock.RegisterResponder(http.MethodGet,
+					fmt.Sprintf("%s/v1/getpageslist/?projectid=1&publickey=public&secretkey=secret", apiBaseUrl),
+					httpmock.NewStringResponder(http.StatusOK, "{"))
+			},
+			wantHTML: []string{"body 21"},
+			wantErr:  true,
+		}, {
+			name: "projects list failed",
+			registerResponder: func() {
+				httpmock.RegisterResponder(http.MethodGet,
+					fmt.Sprintf("%s/v1/getprojectslist/?publickey=public&secretkey=secret", apiBaseUrl),
+					httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			tt.registerResponder()
+
+			c := NewClient(&Config{
+				PublicKey: "public",
+				SecretKey: "secret",
+			})
+
+			var gotHTML []string
+			err := c.Crawl(context.Background(), tt.opts, func(page CrawledPage) error {
+				assert.Equal(t, "Project "+page.Project.ID, page.Project.Title)
+
+				switch {
+				case page.Full != nil:
+					gotHTML = append(gotHTML, page.Full.HTML)
+				case page.Export != nil:
+					gotHTML = append(gotHTML, page.Export.HTML)
+				default:
+					gotHTML = append(gotHTML, page.Page.HTML)
+				}
+
+				return nil
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantHTML, gotHTML)
+		})
+	}
+}
+
+func TestClient_Crawl_Stop(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerCrawlAccount()
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+
+	errStop := errors.New("stop")
+	var visited int
+	err := c.Crawl(context.Background(), CrawlOptions{Concurrency: 2}, func(page CrawledPage) error {
+		visited++
+
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, visited)
+}
@@ -24,3 +24,13 @@ func (d *DateTime) UnmarshalJSON(b []byte) error {
 	*d = DateTime(t)
 	return nil
 }
+
+// MarshalJSON encodes date in the same format Tilda uses
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	t := time.Time(d)
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + t.Format(dtFormat) + `"`), nil
+}
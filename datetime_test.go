@@ -45,3 +45,32 @@ func TestDateTime_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestDateTime_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		d    DateTime
+		want string
+	}{
+		{
+			name: "success",
+			d:    DateTime(time.Date(2021, 9, 1, 15, 4, 5, 0, time.UTC)),
+			want: `"2021-09-01 15:04:05"`,
+		}, {
+			name: "zero",
+			d:    DateTime(time.Time{}),
+			want: `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.d.MarshalJSON()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			var dt DateTime
+			assert.NoError(t, dt.UnmarshalJSON(got))
+			assert.Equal(t, tt.d, dt)
+		})
+	}
+}
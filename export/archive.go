@@ -0,0 +1,324 @@
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// ArchiveFormat is the format of archive written by Archive
+type ArchiveFormat int
+
+// Supported archive formats
+const (
+	ArchiveZip ArchiveFormat = iota
+	ArchiveTarGz
+)
+
+// archiveEpoch is the modification time of files when no time is known (the earliest time zip supports)
+var archiveEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Archive is the storage writing files as zip or tar.gz archive on Close. Contents are spooled into a temporary
+// file as they are put, only names, sizes, checksums and modification times are kept in memory.
+// Files are written sorted by name with fixed permissions and modification times, so identical content
+// produces identical archives.
+type Archive struct {
+	w       io.Writer
+	format  ArchiveFormat
+	mu      sync.Mutex
+	files   map[string]archiveFile
+	closed  bool
+	spoolMu sync.Mutex // Serializes writes into the spool
+	spool   *os.File   // Temporary file with contents of all files put, created on the first Put
+	end     int64      // Size of the spool
+}
+
+type archiveFile struct {
+	offset  int64 // Offset of the content in the spool
+	size    int64
+	sha256  string // Hex-encoded SHA-256 checksum of the content
+	modTime time.Time
+}
+
+// NewArchive creates new archive of the format written to w
+func NewArchive(w io.Writer, format ArchiveFormat) *Archive {
+	return &Archive{
+		w:      w,
+		format: format,
+		files:  make(map[string]archiveFile),
+	}
+}
+
+// ProjectArchive exports the project (see Exporter.Project) into archive of the format written to w.
+// Pages are timestamped with their Date, other files with the latest Date of all pages.
+func ProjectArchive(ctx context.Context, client *tilda.Client, projectID string, w io.Writer, format ArchiveFormat, options ...func(*Exporter)) (*Result, error) {
+	archive := NewArchive(w, format)
+	result, exportErr := NewExporter(client, archive, options...).Project(ctx, projectID)
+	if result == nil {
+		return nil, errors.Join(exportErr, archive.discard())
+	}
+
+	for _, page := range result.Pages {
+		modTime := time.Time(page.Date)
+		archive.SetModTime(page.Path, modTime)
+		for _, name := range page.Copies {
+			archive.SetModTime(name, modTime)
+		}
+	}
+
+	// The archive is always a full export, there is no previous state to compare with
+	if err := archive.Delete(ctx, StateFilename); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("close archive: %w", err)
+	}
+
+	return result, exportErr
+}
+
+// Put spools content of the file until the archive is closed
+func (a *Archive) Put(ctx context.Context, name string, r io.Reader) error {
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+
+	a.spoolMu.Lock()
+	defer a.spoolMu.Unlock()
+
+	a.mu.Lock()
+	closed := a.closed
+	a.mu.Unlock()
+	if closed {
+		return fmt.Errorf("put %s: archive is closed", name)
+	}
+
+	if a.spool == nil {
+		spool, err := os.CreateTemp("", "tilda-archive-*")
+		if err != nil {
+			return fmt.Errorf("create spool: %w", err)
+		}
+
+		a.spool = spool
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(io.NewOffsetWriter(a.spool, a.end), h), contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file := a.files[name]
+	file.offset = a.end
+	file.size = size
+	file.sha256 = hex.EncodeToString(h.Sum(nil))
+	a.files[name] = file
+	a.end += size
+
+	return nil
+}
+
+// Get opens the file put into the archive
+func (a *Archive) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, ok := a.files[name]
+	if !ok || a.closed {
+		return nil, fmt.Errorf("open %s: %w", name, fs.ErrNotExist)
+	}
+
+	return io.NopCloser(io.NewSectionReader(a.spool, file.offset, file.size)), nil
+}
+
+// Checksum returns hex-encoded SHA-256 checksum of the file without reading it
+func (a *Archive) Checksum(_ context.Context, name string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, ok := a.files[name]
+	if !ok {
+		return "", fmt.Errorf("open %s: %w", name, fs.ErrNotExist)
+	}
+
+	return file.sha256, nil
+}
+
+// Delete removes the file from the archive
+func (a *Archive) Delete(_ context.Context, name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.files, name)
+
+	return nil
+}
+
+// List returns sorted names of files put into the archive starting with the prefix
+func (a *Archive) List(_ context.Context, prefix string) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var names []string
+	for name := range a.files {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// SetModTime sets modification time of the file. Files without modification time get the latest time
+// set for other files.
+func (a *Archive) SetModTime(name string, modTime time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, ok := a.files[name]
+	if !ok {
+		return
+	}
+
+	file.modTime = modTime
+	a.files[name] = file
+}
+
+// Close writes all files into the archive and removes the spool. It doesn't close the underlying writer.
+func (a *Archive) Close() error {
+	a.spoolMu.Lock()
+	defer a.spoolMu.Unlock()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	defer a.removeSpool()
+
+	names := make([]string, 0, len(a.files))
+	latest := archiveEpoch
+	for name, file := range a.files {
+		names = append(names, name)
+		if file.modTime.After(latest) {
+			latest = file.modTime
+		}
+	}
+	sort.Strings(names)
+
+	modTime := func(file archiveFile) time.Time {
+		if file.modTime.Before(archiveEpoch) {
+			return latest.UTC()
+		}
+
+		return file.modTime.UTC()
+	}
+
+	switch a.format {
+	case ArchiveZip:
+		return a.writeZip(names, modTime)
+	case ArchiveTarGz:
+		return a.writeTarGz(names, modTime)
+	}
+
+	return fmt.Errorf("unknown archive format %d", a.format)
+}
+
+// discard closes the archive without writing it
+func (a *Archive) discard() error {
+	a.spoolMu.Lock()
+	defer a.spoolMu.Unlock()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.closed = true
+
+	return a.removeSpool()
+}
+
+func (a *Archive) removeSpool() error {
+	if a.spool == nil {
+		return nil
+	}
+
+	spool := a.spool
+	a.spool = nil
+
+	return errors.Join(spool.Close(), os.Remove(spool.Name()))
+}
+
+// content returns reader of the file content in the spool
+func (a *Archive) content(file archiveFile) io.Reader {
+	return io.NewSectionReader(a.spool, file.offset, file.size)
+}
+
+func (a *Archive) writeZip(names []string, modTime func(archiveFile) time.Time) error {
+	zw := zip.NewWriter(a.w)
+	for _, name := range names {
+		file := a.files[name]
+		header := &zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: modTime(file),
+		}
+		header.SetMode(0o644)
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", name, err)
+		}
+
+		if _, err := io.Copy(fw, a.content(file)); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+func (a *Archive) writeTarGz(names []string, modTime func(archiveFile) time.Time) error {
+	gw := gzip.NewWriter(a.w)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		file := a.files[name]
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     file.size,
+			ModTime:  modTime(file),
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write header %s: %w", name, err)
+		}
+
+		if _, err := io.Copy(tw, a.content(file)); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+
+	return errors.Join(tw.Close(), gw.Close())
+}
@@ -0,0 +1,151 @@
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	archive := NewArchive(&buf, ArchiveZip)
+
+	assert.NoError(t, archive.Put(ctx, "css/style.css", strings.NewReader("body{}")))
+	assert.NoError(t, archive.Put(ctx, "index.html", strings.NewReader("<html></html>")))
+	assert.NoError(t, archive.Put(ctx, "tmp.txt", strings.NewReader("tmp")))
+	assert.NoError(t, archive.Put(ctx, "css/style.css", strings.NewReader("body{margin:0}")))
+	assert.Error(t, archive.Put(ctx, "../outside.html", strings.NewReader("")))
+
+	rc, err := archive.Get(ctx, "css/style.css")
+	assert.NoError(t, err)
+	bts, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "body{margin:0}", string(bts))
+
+	sum, err := checksum(ctx, archive, "css/style.css")
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex("body{margin:0}"), sum)
+	_, err = checksum(ctx, archive, "missing.css")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.NoError(t, archive.Delete(ctx, "tmp.txt"))
+	_, err = archive.Get(ctx, "tmp.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	archive.SetModTime("unknown.html", time.Now())
+	names, err := archive.List(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"css/style.css", "index.html"}, names)
+
+	pageDate := time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC)
+	archive.SetModTime("index.html", pageDate)
+	spool := archive.spool.Name()
+	assert.NoError(t, archive.Close())
+	assert.Error(t, archive.Put(ctx, "late.html", strings.NewReader("")))
+	_, err = os.Stat(spool)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	if assert.Len(t, zr.File, 2) {
+		assert.Equal(t, "css/style.css", zr.File[0].Name)
+		assert.True(t, pageDate.Equal(zr.File[0].Modified))
+		assert.Equal(t, "index.html", zr.File[1].Name)
+		assert.True(t, pageDate.Equal(zr.File[1].Modified))
+
+		rc, err := zr.File[0].Open()
+		assert.NoError(t, err)
+		bts, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		assert.Equal(t, "body{margin:0}", string(bts))
+	}
+}
+
+func TestProjectArchive(t *testing.T) {
+	tests := []struct {
+		name   string
+		format ArchiveFormat
+		read   func(t *testing.T, data []byte) map[string]time.Time
+	}{
+		{
+			name:   "zip",
+			format: ArchiveZip,
+			read: func(t *testing.T, data []byte) map[string]time.Time {
+				zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				assert.NoError(t, err)
+
+				files := make(map[string]time.Time)
+				for _, f := range zr.File {
+					files[f.Name] = f.Modified
+				}
+
+				return files
+			},
+		}, {
+			name:   "tar.gz",
+			format: ArchiveTarGz,
+			read: func(t *testing.T, data []byte) map[string]time.Time {
+				gr, err := gzip.NewReader(bytes.NewReader(data))
+				assert.NoError(t, err)
+
+				files := make(map[string]time.Time)
+				tr := tar.NewReader(gr)
+				for {
+					header, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					assert.NoError(t, err)
+					files[header.Name] = header.ModTime
+				}
+
+				return files
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			first := testPage("1", 10, "")
+			second := testPage("2", 20, "blog")
+			second.Date = tilda.DateTime(time.Time(second.Date).Add(time.Hour))
+			registerTestProject(first, second)
+
+			var archives [2]bytes.Buffer
+			for i := range archives {
+				result, err := ProjectArchive(context.Background(), newTestClient(), "54321", &archives[i], tt.format, WithPageConcurrency(2))
+				assert.NoError(t, err)
+				assert.Len(t, result.Pages, 2)
+			}
+			assert.Equal(t, archives[0].Bytes(), archives[1].Bytes())
+
+			files := tt.read(t, archives[0].Bytes())
+			assert.Len(t, files, 11)
+			assert.Contains(t, files, ManifestFilename)
+			assert.Contains(t, files, RobotsFilename)
+			assert.NotContains(t, files, StateFilename)
+
+			firstDate := time.Time(first.Date)
+			secondDate := time.Time(second.Date)
+			assert.True(t, firstDate.Equal(files["page1.html"]))
+			assert.True(t, firstDate.Equal(files["index.html"]))
+			assert.True(t, secondDate.Equal(files["blog/index.html"]))
+			assert.True(t, secondDate.Equal(files["css/fonts-tildasans.css"]))
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// Composer wraps body-only pages (GetPage, GetPageExport) with header and footer pages of the project
+// and renders them into full documents
+type Composer struct {
+	client   *tilda.Client
+	mu       sync.Mutex
+	projects map[string]*projectEntry
+}
+
+// projectEntry represents header and footer of the project being fetched or fetched already.
+// Concurrent calls for the same project wait for the first one instead of fetching again.
+type projectEntry struct {
+	done  chan struct{} // Closed when parts are fetched
+	parts *projectParts
+	err   error
+}
+
+// projectParts represents header and footer of the project fetched once for all its pages
+type projectParts struct {
+	project tilda.ProjectInfo
+	header  *tilda.PageExport
+	footer  *tilda.PageExport
+}
+
+// allRecords represents position of #allrecords element in HTML code
+type allRecords struct {
+	attrs      []html.Attribute
+	innerStart int // Offset of the first byte after the start tag
+	innerEnd   int // Offset of the end tag
+}
+
+// NewComposer creates new composer fetching header and footer pages with client
+func NewComposer(client *tilda.Client) *Composer {
+	return &Composer{
+		client:   client,
+		projects: make(map[string]*projectEntry),
+	}
+}
+
+// Compose returns page for export with full document HTML code containing header and footer of the project.
+// Images, scripts and styles of header and footer are merged into page assets without duplicates.
+func (c *Composer) Compose(ctx context.Context, page tilda.PageExport) (tilda.PageExport, error) {
+	parts, err := c.parts(ctx, page.ProjectID)
+	if err != nil {
+		return tilda.PageExport{}, err
+	}
+
+	body, err := composeBody(page.HTML, parts)
+	if err != nil {
+		return tilda.PageExport{}, err
+	}
+
+	composed := page
+	for _, part := range []*tilda.PageExport{parts.header, parts.footer} {
+		if part == nil || part.ID == page.ID {
+			continue
+		}
+
+		composed.Images = mergeAssets(composed.Images, part.Images, func(img tilda.Image) string { return img.From })
+		composed.JS = mergeAssets(composed.JS, part.JS, func(js tilda.JS) string { return js.From })
+		composed.CSS = mergeAssets(composed.CSS, part.CSS, func(css tilda.CSS) string { return css.From })
+	}
+
+	doc := PageDocument(composed, parts.project)
+	doc.Body = body
+	composed.HTML = doc.Render()
+
+	return composed, nil
+}
+
+// ComposePage returns page with full document HTML code containing header and footer of the project.
+// Scripts and styles of header and footer are merged into page assets without duplicates.
+func (c *Composer) ComposePage(ctx context.Context, page tilda.Page) (tilda.Page, error) {
+	parts, err := c.parts(ctx, page.ProjectID)
+	if err != nil {
+		return tilda.Page{}, err
+	}
+
+	body, err := composeBody(page.HTML, parts)
+	if err != nil {
+		return tilda.Page{}, err
+	}
+
+	composed := page
+	identity := func(u string) string { return u }
+	for _, part := range []*tilda.PageExport{parts.header, parts.footer} {
+		if part == nil || part.ID == page.ID {
+			continue
+		}
+
+		for _, js := range part.JS {
+			composed.JS = mergeAssets(composed.JS, []string{js.From}, identity)
+		}
+		for _, css := range part.CSS {
+			composed.CSS = mergeAssets(composed.CSS, []string{css.From}, identity)
+		}
+	}
+
+	doc := BodyPageDocument(composed, parts.project)
+	doc.Body = body
+	composed.HTML = doc.Render()
+
+	return composed, nil
+}
+
+// parts returns header and footer of the project fetching them on the first call.
+// Failed fetches are not cached, so the next call tries again.
+func (c *Composer) parts(ctx context.Context, projectID string) (*projectParts, error) {
+	c.mu.Lock()
+	entry, ok := c.projects[projectID]
+	if !ok {
+		entry = &projectEntry{done: make(chan struct{})}
+		c.projects[projectID] = entry
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-entry.done:
+			return entry.parts, entry.err
+		}
+	}
+
+	entry.parts, entry.err = c.fetchParts(ctx, projectID)
+	if entry.err != nil {
+		c.mu.Lock()
+		delete(c.projects, projectID)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+
+	return entry.parts, entry.err
+}
+
+// fetchParts fetches the project with its header and footer pages
+func (c *Composer) fetchParts(ctx context.Context, projectID string) (*projectParts, error) {
+	project, err := c.client.GetProjectExport(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("get project export: %w", err)
+	}
+
+	parts := &projectParts{project: project}
+	if isPageID(project.HeaderpageID) {
+		header, err := c.client.GetPageExport(ctx, project.HeaderpageID)
+		if err != nil {
+			return nil, fmt.Errorf("get header page: %w", err)
+		}
+
+		parts.header = &header
+	}
+	if isPageID(project.FooterpageID) {
+		footer, err := c.client.GetPageExport(ctx, project.FooterpageID)
+		if err != nil {
+			return nil, fmt.Errorf("get footer page: %w", err)
+		}
+
+		parts.footer = &footer
+	}
+
+	return parts, nil
+}
+
+// composeBody inserts records of header and footer pages into #allrecords element of the page
+// the same way Tilda does it
+func composeBody(body string, parts *projectParts) (string, error) {
+	records, err := findAllRecords(body)
+	if err != nil {
+		return "", fmt.Errorf("find page records: %w", err)
+	}
+
+	pageID := attrValue(records.attrs, "data-tilda-page-id")
+
+	var header, footer string
+	if parts.header != nil && parts.header.ID != pageID {
+		if header, err = wrapRecords(parts.header.HTML, "header", "t-header"); err != nil {
+			return "", fmt.Errorf("wrap header records: %w", err)
+		}
+	}
+	if parts.footer != nil && parts.footer.ID != pageID {
+		if footer, err = wrapRecords(parts.footer.HTML, "footer", "t-footer"); err != nil {
+			return "", fmt.Errorf("wrap footer records: %w", err)
+		}
+	}
+
+	return body[:records.innerStart] + header + body[records.innerStart:records.innerEnd] + footer + body[records.innerEnd:], nil
+}
+
+// wrapRecords moves records of header or footer page into element with the tag name
+func wrapRecords(partHTML, tag, id string) (string, error) {
+	records, err := findAllRecords(partHTML)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("<!--" + tag + "--><" + tag + ` id="` + id + `"`)
+	for _, attr := range records.attrs {
+		if attr.Key == "id" {
+			continue
+		}
+
+		b.WriteString(" " + attr.Key + `="` + template.HTMLEscapeString(attr.Val) + `"`)
+	}
+	b.WriteString(">")
+	b.WriteString(partHTML[records.innerStart:records.innerEnd])
+	b.WriteString("</" + tag + "><!--/" + tag + "-->")
+
+	return b.String(), nil
+}
+
+// findAllRecords finds #allrecords element containing page records
+func findAllRecords(htmlCode string) (allRecords, error) {
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlCode))
+	offset := 0
+	depth := -1
+	var records allRecords
+	for {
+		tokenType := tokenizer.Next()
+		rawLen := len(tokenizer.Raw())
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return allRecords{}, fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return allRecords{}, fmt.Errorf("#allrecords element not found")
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			if token.Data != "div" {
+				break
+			}
+
+			if depth >= 0 {
+				depth++
+			} else if attrValue(token.Attr, "id") == "allrecords" {
+				depth = 0
+				records.attrs = token.Attr
+				records.innerStart = offset + rawLen
+			}
+		case html.EndTagToken:
+			if depth < 0 {
+				break
+			}
+
+			if name, _ := tokenizer.TagName(); string(name) == "div" {
+				if depth == 0 {
+					records.innerEnd = offset
+
+					return records, nil
+				}
+
+				depth--
+			}
+		}
+
+		offset += rawLen
+	}
+}
+
+func attrValue(attrs []html.Attribute, key string) string {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+// mergeAssets returns the list with missing assets appended keeping the order. The list is copied,
+// so that the backing array shared with the caller (e.g. cached header assets) is never written.
+func mergeAssets[T any](list, extra []T, key func(T) string) []T {
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		seen[key(item)] = true
+	}
+
+	merged := make([]T, len(list), len(list)+len(extra))
+	copy(merged, list)
+	for _, item := range extra {
+		if seen[key(item)] {
+			continue
+		}
+
+		seen[key(item)] = true
+		merged = append(merged, item)
+	}
+
+	return merged
+}
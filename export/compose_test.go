@@ -0,0 +1,235 @@
+package export
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func registerComposeProject(headerID, footerID string) {
+	registerAPI("getprojectexport", "projectid=54321", tilda.ProjectInfo{
+		ID:           "54321",
+		Title:        "My Site",
+		HeaderpageID: headerID,
+		FooterpageID: footerID,
+	})
+	registerAPI("getpageexport", "pageid=10", tilda.PageExport{
+		ID:        "10",
+		ProjectID: "54321",
+		HTML:      `<!--allrecords--><div id="allrecords" class="t-records" data-tilda-page-id="10"><div id="rec1" class="r">Menu</div></div><!--/allrecords-->`,
+		Images:    []tilda.Image{{From: "https://static.tildacdn.com/img/logo.png", To: "logo.png"}},
+		JS:        []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"}, {From: "https://static.tildacdn.com/js/tilda-menu-1.0.min.js", To: "tilda-menu-1.0.min.js"}},
+		CSS:       []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css", To: "tilda-grid-3.0.min.css"}},
+	})
+	registerAPI("getpageexport", "pageid=20", tilda.PageExport{
+		ID:        "20",
+		ProjectID: "54321",
+		HTML:      `<!--allrecords--><div id="allrecords" class="t-records" data-tilda-page-id="20"><div id="rec2" class="r">Copyright</div></div><!--/allrecords-->`,
+		CSS:       []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css", To: "tilda-grid-3.0.min.css"}},
+	})
+}
+
+func TestComposer_Compose(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerComposeProject("10", "20")
+
+	page := tilda.PageExport{
+		ID:        "1",
+		ProjectID: "54321",
+		Title:     "Home",
+		HTML:      `<!--allrecords--><div id="allrecords" class="t-records" data-tilda-page-id="1"><div id="rec3" class="r"><div>Content</div></div></div><!--/allrecords-->`,
+		JS:        []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"}},
+		CSS:       []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css", To: "tilda-grid-3.0.min.css"}},
+	}
+
+	composer := NewComposer(newTestClient())
+	composed, err := composer.Compose(context.Background(), page)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []tilda.Image{{From: "https://static.tildacdn.com/img/logo.png", To: "logo.png"}}, composed.Images)
+	assert.Equal(t, []tilda.JS{
+		{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"},
+		{From: "https://static.tildacdn.com/js/tilda-menu-1.0.min.js", To: "tilda-menu-1.0.min.js"},
+	}, composed.JS)
+	assert.Equal(t, []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css", To: "tilda-grid-3.0.min.css"}}, composed.CSS)
+	assert.Equal(t, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Home</title>
+<meta property="og:title" content="Home">
+<meta property="og:type" content="website">
+<link rel="stylesheet" href="https://static.tildacdn.com/css/tilda-grid-3.0.min.css">
+<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"></script>
+<script src="https://static.tildacdn.com/js/tilda-menu-1.0.min.js"></script>
+</head>
+<body class="t-body" style="margin:0;">
+<!--allrecords--><div id="allrecords" class="t-records" data-tilda-page-id="1"><!--header--><header id="t-header" class="t-records" data-tilda-page-id="10"><div id="rec1" class="r">Menu</div></header><!--/header--><div id="rec3" class="r"><div>Content</div></div><!--footer--><footer id="t-footer" class="t-records" data-tilda-page-id="20"><div id="rec2" class="r">Copyright</div></footer><!--/footer--></div><!--/allrecords-->
+</body>
+</html>
+`, composed.HTML)
+
+	_, err = composer.Compose(context.Background(), tilda.PageExport{
+		ID:        "2",
+		ProjectID: "54321",
+		HTML:      `<div id="allrecords" data-tilda-page-id="2"></div>`,
+	})
+	assert.NoError(t, err)
+
+	info := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, info["GET "+testAPIBaseURL+"/v1/getprojectexport/?projectid=54321&publickey=public&secretkey=secret"])
+	assert.Equal(t, 1, info["GET "+testAPIBaseURL+"/v1/getpageexport/?pageid=10&publickey=public&secretkey=secret"])
+	assert.Equal(t, 1, info["GET "+testAPIBaseURL+"/v1/getpageexport/?pageid=20&publickey=public&secretkey=secret"])
+}
+
+func TestComposer_ComposePage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerComposeProject("10", "0")
+
+	composed, err := NewComposer(newTestClient()).ComposePage(context.Background(), tilda.Page{
+		ID:        "1",
+		ProjectID: "54321",
+		Title:     "Home",
+		HTML:      `<div id="allrecords" data-tilda-page-id="1"><div id="rec3">Content</div></div>`,
+		JS:        []string{"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js",
+		"https://static.tildacdn.com/js/tilda-menu-1.0.min.js",
+	}, composed.JS)
+	assert.Equal(t, []string{"https://static.tildacdn.com/css/tilda-grid-3.0.min.css"}, composed.CSS)
+	assert.Contains(t, composed.HTML, `<div id="allrecords" data-tilda-page-id="1"><!--header--><header id="t-header" class="t-records" data-tilda-page-id="10"><div id="rec1" class="r">Menu</div></header><!--/header--><div id="rec3">Content</div></div>`)
+	assert.NotContains(t, composed.HTML, "t-footer")
+}
+
+func TestComposer_HeaderPage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerComposeProject("10", "")
+
+	composed, err := NewComposer(newTestClient()).Compose(context.Background(), tilda.PageExport{
+		ID:        "10",
+		ProjectID: "54321",
+		HTML:      `<div id="allrecords" data-tilda-page-id="10"><div id="rec1">Menu</div></div>`,
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, composed.HTML, "t-header")
+	assert.Nil(t, composed.Images)
+}
+
+func TestComposer_Concurrent(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerComposeProject("10", "20")
+
+	// Project 99 responds only after project 54321 is composed, so a lock held during requests would deadlock
+	slowURL := testAPIBaseURL + "/v1/getprojectexport/?projectid=99&publickey=public&secretkey=secret"
+	release := make(chan struct{})
+	httpmock.RegisterResponder(http.MethodGet, slowURL, func(req *http.Request) (*http.Response, error) {
+		<-release
+		return httpmock.NewJsonResponse(http.StatusOK, tilda.NewResponse(tilda.ProjectInfo{ID: "99"}))
+	})
+
+	composer := NewComposer(newTestClient())
+	slowDone := make(chan error)
+	go func() {
+		_, err := composer.Compose(context.Background(), tilda.PageExport{ID: "3", ProjectID: "99", HTML: `<div id="allrecords"></div>`})
+		slowDone <- err
+	}()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"1", "2", "4"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := composer.Compose(context.Background(), tilda.PageExport{ID: id, ProjectID: "54321", HTML: `<div id="allrecords"></div>`})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	close(release)
+	assert.NoError(t, <-slowDone)
+
+	info := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, info["GET "+testAPIBaseURL+"/v1/getprojectexport/?projectid=54321&publickey=public&secretkey=secret"])
+	assert.Equal(t, 1, info["GET "+slowURL])
+}
+
+func TestComposer_ProjectFailed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	url := testAPIBaseURL + "/v1/getprojectexport/?projectid=54321&publickey=public&secretkey=secret"
+	httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+
+	composer := NewComposer(newTestClient())
+	page := tilda.PageExport{ID: "1", ProjectID: "54321", HTML: `<div id="allrecords"></div>`}
+	_, err := composer.Compose(context.Background(), page)
+	assert.Error(t, err)
+
+	// Failures are not cached, so the project is requested again
+	registerComposeProject("", "")
+	_, err = composer.Compose(context.Background(), page)
+	assert.NoError(t, err)
+}
+
+func TestMergeAssets(t *testing.T) {
+	list := make([]string, 1, 4)
+	list[0] = "a.js"
+	backing := list[:cap(list)]
+
+	merged := mergeAssets(list, []string{"a.js", "b.js"}, func(s string) string { return s })
+	assert.Equal(t, []string{"a.js", "b.js"}, merged)
+
+	// The spare capacity of the list is not written
+	assert.Equal(t, []string{"a.js", "", "", ""}, backing)
+}
+
+func TestFindAllRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "nested",
+			html: `<body><div id="allrecords"><div><div>a</div></div><script>var s = "</div>";</script></div><div>b</div></body>`,
+			want: `<div><div>a</div></div><script>var s = "</div>";</script>`,
+		}, {
+			name: "empty",
+			html: `<div id="allrecords"></div>`,
+			want: ``,
+		}, {
+			name:    "missing",
+			html:    `<div id="records"></div>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := findAllRecords(tt.html)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.html[records.innerStart:records.innerEnd])
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+)
+
+// Files of ContentStore in its storage
+const (
+	contentBlobsDir      = "blobs"
+	contentIndexFilename = "index.json"
+)
+
+// ContentStore keeps asset contents in the storage keyed by SHA-256 checksum, so assets repeated across pages
+// and projects are downloaded and stored once. Store counts owners (e.g. pages) referencing every blob,
+// GC removes blobs that are not referenced by any owner.
+type ContentStore struct {
+	storage Storage
+	mu      sync.Mutex
+	index   contentIndex
+	fresh   map[string]bool // Source URLs downloaded or revalidated during the store lifetime
+}
+
+type contentIndex struct {
+	Blobs   map[string]int64         `json:"blobs"`   // Size of blob by checksum
+	Refs    map[string][]string      `json:"refs"`    // Sorted checksums of blobs referenced by owner
+	Sources map[string]contentSource `json:"sources"` // The last response by source URL
+}
+
+// contentSource represents the last response of the source URL. Tilda updates some assets keeping their URLs,
+// so content of sources is reused by later stores only after conditional request with the validators.
+type contentSource struct {
+	SHA256       string `json:"sha256"`                  // Checksum of the blob with the response content
+	ETag         string `json:"etag,omitempty"`          // ETag of the response
+	LastModified string `json:"last_modified,omitempty"` // Last-Modified of the response
+}
+
+// OpenContentStore loads index of the store from the storage or creates empty store if there is no index yet
+func OpenContentStore(ctx context.Context, storage Storage) (*ContentStore, error) {
+	store := &ContentStore{
+		storage: storage,
+		index: contentIndex{
+			Blobs:   make(map[string]int64),
+			Refs:    make(map[string][]string),
+			Sources: make(map[string]contentSource),
+		},
+		fresh: make(map[string]bool),
+	}
+
+	rc, err := storage.Get(ctx, contentIndexFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get index: %w", err)
+	}
+	defer rc.Close()
+
+	var index contentIndex
+	if err := json.NewDecoder(rc).Decode(&index); err != nil {
+		return nil, fmt.Errorf("unmarshal index: %w", err)
+	}
+
+	for sum, size := range index.Blobs {
+		store.index.Blobs[sum] = size
+	}
+	for owner, sums := range index.Refs {
+		store.index.Refs[owner] = sums
+	}
+	for sourceURL, source := range index.Sources {
+		store.index.Sources[sourceURL] = source
+	}
+
+	return store, nil
+}
+
+// PageOwner returns owner name of the page used for reference counting
+func PageOwner(projectID, pageID string) string {
+	return projectID + "/" + pageID
+}
+
+// ProjectOwner returns owner name of the project (e.g. for favicon) used for reference counting
+func ProjectOwner(projectID string) string {
+	return projectID
+}
+
+// Put stores content downloaded from source URL and returns its checksum. Content is written once,
+// storing the same content again only remembers the source URL.
+func (s *ContentStore) Put(ctx context.Context, sourceURL string, body []byte) (string, error) {
+	return s.putSource(ctx, sourceURL, body, "", "")
+}
+
+// putSource stores content of the response to source URL remembering its validators
+func (s *ContentStore) putSource(ctx context.Context, sourceURL string, body []byte, etag, lastModified string) (string, error) {
+	sum := sha256.Sum256(body)
+	hexSum := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	_, exists := s.index.Blobs[hexSum]
+	s.mu.Unlock()
+
+	if !exists {
+		if err := s.storage.Put(ctx, blobPath(hexSum), bytes.NewReader(body)); err != nil {
+			return "", fmt.Errorf("put blob: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index.Blobs[hexSum] = int64(len(body))
+	if sourceURL != "" {
+		s.index.Sources[sourceURL] = contentSource{SHA256: hexSum, ETag: etag, LastModified: lastModified}
+		s.fresh[sourceURL] = true
+	}
+
+	return hexSum, nil
+}
+
+// Lookup returns checksum of content downloaded from source URL during the store lifetime
+func (s *ContentStore) Lookup(sourceURL string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.fresh[sourceURL] {
+		return "", false
+	}
+
+	return s.sourceLocked(sourceURL)
+}
+
+// source returns the last response to source URL saved in the index, possibly by other store.
+// The content may be outdated, so it is reused only after conditional request with the validators.
+func (s *ContentStore) source(sourceURL string) (contentSource, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sourceLocked(sourceURL); !ok {
+		return contentSource{}, false
+	}
+
+	return s.index.Sources[sourceURL], true
+}
+
+// revalidated marks content of source URL as up to date for the store lifetime
+func (s *ContentStore) revalidated(sourceURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fresh[sourceURL] = true
+}
+
+func (s *ContentStore) sourceLocked(sourceURL string) (string, bool) {
+	source, ok := s.index.Sources[sourceURL]
+	if !ok {
+		return "", false
+	}
+
+	_, ok = s.index.Blobs[source.SHA256]
+
+	return source.SHA256, ok
+}
+
+// Open opens the blob with the checksum
+func (s *ContentStore) Open(ctx context.Context, sum string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	_, ok := s.index.Blobs[sum]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("blob %s: %w", sum, fs.ErrNotExist)
+	}
+
+	return s.storage.Get(ctx, blobPath(sum))
+}
+
+// SetRefs replaces blobs referenced by the owner
+func (s *ContentStore) SetRefs(owner string, sums []string) {
+	unique := make([]string, 0, len(sums))
+	seen := make(map[string]bool, len(sums))
+	for _, sum := range sums {
+		if !seen[sum] {
+			seen[sum] = true
+			unique = append(unique, sum)
+		}
+	}
+	sort.Strings(unique)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(unique) == 0 {
+		delete(s.index.Refs, owner)
+		return
+	}
+
+	s.index.Refs[owner] = unique
+}
+
+// Release removes all references of the owner
+func (s *ContentStore) Release(owner string) {
+	s.SetRefs(owner, nil)
+}
+
+// RefCount returns the number of owners referencing the blob
+func (s *ContentStore) RefCount(sum string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.refCounts()[sum]
+}
+
+// GC removes blobs that are not referenced by any owner and saves the index. Removed checksums are returned sorted.
+func (s *ContentStore) GC(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	refs := s.refCounts()
+	var unreferenced []string
+	for sum := range s.index.Blobs {
+		if refs[sum] == 0 {
+			unreferenced = append(unreferenced, sum)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(unreferenced)
+
+	removed := make([]string, 0, len(unreferenced))
+	for _, sum := range unreferenced {
+		if err := s.storage.Delete(ctx, blobPath(sum)); err != nil {
+			return removed, fmt.Errorf("delete blob: %w", err)
+		}
+
+		s.mu.Lock()
+		delete(s.index.Blobs, sum)
+		for sourceURL, source := range s.index.Sources {
+			if source.SHA256 == sum {
+				delete(s.index.Sources, sourceURL)
+				delete(s.fresh, sourceURL)
+			}
+		}
+		s.mu.Unlock()
+
+		removed = append(removed, sum)
+	}
+
+	return removed, s.Save(ctx)
+}
+
+// Save writes the index of blobs, references and source URLs into the storage
+func (s *ContentStore) Save(ctx context.Context) error {
+	s.mu.Lock()
+	bts, err := json.MarshalIndent(s.index, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshal index: %w", err)
+	}
+
+	if err := s.storage.Put(ctx, contentIndexFilename, bytes.NewReader(bts)); err != nil {
+		return fmt.Errorf("put index: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ContentStore) refCounts() map[string]int {
+	counts := make(map[string]int, len(s.index.Blobs))
+	for _, sums := range s.index.Refs {
+		for _, sum := range sums {
+			counts[sum]++
+		}
+	}
+
+	return counts
+}
+
+// blobPath returns path of the blob in the storage (e.g. blobs/ab/abcdef...)
+func blobPath(sum string) string {
+	return path.Join(contentBlobsDir, sum[:2], sum)
+}
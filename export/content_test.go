@@ -0,0 +1,209 @@
+package export
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestContentStore(t *testing.T) {
+	root := t.TempDir()
+	ctx := context.Background()
+
+	store, err := OpenContentStore(ctx, NewDirStorage(root))
+	assert.NoError(t, err)
+
+	sum, err := store.Put(ctx, "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", []byte("js"))
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex("js"), sum)
+
+	again, err := store.Put(ctx, "https://static.tildacdn.com/js/copy.js", []byte("js"))
+	assert.NoError(t, err)
+	assert.Equal(t, sum, again)
+
+	css, err := store.Put(ctx, "", []byte("body{}"))
+	assert.NoError(t, err)
+
+	got, ok := store.Lookup("https://static.tildacdn.com/js/copy.js")
+	assert.True(t, ok)
+	assert.Equal(t, sum, got)
+	_, ok = store.Lookup("https://static.tildacdn.com/css/fonts-tildasans.css")
+	assert.False(t, ok)
+
+	rc, err := store.Open(ctx, sum)
+	assert.NoError(t, err)
+	bts, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Close())
+	assert.Equal(t, "js", string(bts))
+
+	_, err = os.Stat(filepath.Join(root, "blobs", sum[:2], sum))
+	assert.NoError(t, err)
+
+	store.SetRefs(PageOwner("54321", "1"), []string{sum, css, sum})
+	store.SetRefs(PageOwner("54321", "2"), []string{sum})
+	assert.Equal(t, 2, store.RefCount(sum))
+	assert.Equal(t, 1, store.RefCount(css))
+
+	store.Release(PageOwner("54321", "1"))
+	assert.Equal(t, 1, store.RefCount(sum))
+	assert.Equal(t, 0, store.RefCount(css))
+
+	removed, err := store.GC(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{css}, removed)
+
+	_, err = store.Open(ctx, css)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = os.Stat(filepath.Join(root, "blobs", css[:2], css))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	reopened, err := OpenContentStore(ctx, NewDirStorage(root))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reopened.RefCount(sum))
+
+	rc, err = reopened.Open(ctx, sum)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Close())
+
+	// Sources are saved, but their content is known to be up to date only within the store lifetime
+	_, ok = reopened.Lookup("https://static.tildacdn.com/js/tilda-scripts-3.0.min.js")
+	assert.False(t, ok)
+	source, ok := reopened.source("https://static.tildacdn.com/js/tilda-scripts-3.0.min.js")
+	assert.True(t, ok)
+	assert.Equal(t, sum, source.SHA256)
+	_, ok = reopened.source("https://static.tildacdn.com/css/fonts-tildasans.css")
+	assert.False(t, ok)
+}
+
+func TestContentStore_SharedIndex(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// Tilda updates the file keeping its URL
+	scriptsURL := "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"
+	scripts, etag := "scripts v1", `"v1"`
+	var bodies int
+	httpmock.RegisterResponder(http.MethodGet, scriptsURL, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-None-Match") == etag {
+			return httpmock.NewStringResponse(http.StatusNotModified, ""), nil
+		}
+
+		bodies++
+		resp := httpmock.NewStringResponse(http.StatusOK, scripts)
+		resp.Header.Set("ETag", etag)
+
+		return resp, nil
+	})
+
+	root := t.TempDir()
+	ctx := context.Background()
+	assets := []Asset{{URL: scriptsURL, Path: "js/tilda-scripts-3.0.min.js"}}
+	download := func() string {
+		store, err := OpenContentStore(ctx, NewDirStorage(root))
+		assert.NoError(t, err)
+
+		site := t.TempDir()
+		for range 2 {
+			_, err = NewDownloader(NewDirStorage(site), WithContentStore(store)).Download(ctx, assets)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, store.Save(ctx))
+
+		bts, err := os.ReadFile(filepath.Join(site, "js", "tilda-scripts-3.0.min.js"))
+		assert.NoError(t, err)
+
+		return string(bts)
+	}
+
+	assert.Equal(t, "scripts v1", download())
+	assert.Equal(t, 1, bodies)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	// The second store revalidates the content saved by the first one once
+	assert.Equal(t, "scripts v1", download())
+	assert.Equal(t, 1, bodies)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	scripts, etag = "scripts v2", `"v2"`
+	assert.Equal(t, "scripts v2", download())
+	assert.Equal(t, 2, bodies)
+}
+
+func TestDownloader_WithContentStore(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerAsset("https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", "scripts")
+	registerAsset("https://static.tildacdn.com/img/photo1.jpg", "photo 1")
+	registerAsset("https://static.tildacdn.com/img/photo2.jpg", "photo 2")
+
+	ctx := context.Background()
+	store, err := OpenContentStore(ctx, NewDirStorage(t.TempDir()))
+	assert.NoError(t, err)
+
+	page := func(projectID, pageID string) tilda.PageExport {
+		return tilda.PageExport{
+			ID:        pageID,
+			ProjectID: projectID,
+			Images:    []tilda.Image{{From: "https://static.tildacdn.com/img/photo" + pageID + ".jpg", To: "photo" + pageID + ".jpg"}},
+			JS:        []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"}},
+		}
+	}
+
+	firstRoot := t.TempDir()
+	_, err = NewDownloader(NewDirStorage(firstRoot), WithContentStore(store)).DownloadPage(ctx, page("100", "1"))
+	assert.NoError(t, err)
+
+	secondRoot := t.TempDir()
+	results, err := NewDownloader(NewDirStorage(secondRoot), WithContentStore(store)).DownloadPage(ctx, page("200", "2"))
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	bts, err := os.ReadFile(filepath.Join(secondRoot, "js", "tilda-scripts-3.0.min.js"))
+	assert.NoError(t, err)
+	assert.Equal(t, "scripts", string(bts))
+
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"])
+	assert.Equal(t, 2, store.RefCount(sha256Hex("scripts")))
+	assert.Equal(t, 1, store.RefCount(sha256Hex("photo 1")))
+
+	_, err = NewDownloader(nil, WithContentStore(store)).DownloadPage(ctx, tilda.PageExport{ID: "1", ProjectID: "100"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.RefCount(sha256Hex("scripts")))
+
+	removed, err := store.GC(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{sha256Hex("photo 1")}, removed)
+}
+
+func TestProject_WithContentStore(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+
+	ctx := context.Background()
+	storeRoot := t.TempDir()
+	store, err := OpenContentStore(ctx, NewDirStorage(storeRoot))
+	assert.NoError(t, err)
+
+	dest := NewDirStorage(t.TempDir())
+	_, err = Project(ctx, newTestClient(), "54321", dest, WithDownloader(NewDownloader(dest, WithContentStore(store))))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, store.RefCount(sha256Hex("scripts")))
+	assert.Equal(t, 1, store.RefCount(sha256Hex("favicon")))
+
+	reopened, err := OpenContentStore(ctx, NewDirStorage(storeRoot))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reopened.RefCount(sha256Hex("fonts")))
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"html/template"
+	"path"
+	"strings"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// Document represents full HTML document built around body HTML code of the page
+type Document struct {
+	Lang         string           // Value of lang attribute of html element (not set if empty)
+	Title        string           // Title and og:title
+	Description  string           // Meta description and og:description
+	Image        string           // URL of og:image
+	CanonicalURL string           // Canonical link and og:url
+	Favicon      string           // URL of favicon
+	Headcode     string           // Custom HTML code added to the end of head element as is
+	CSS          []tilda.CSS      // Stylesheets in the order they should be included
+	JS           []tilda.JS       // Scripts in the order they should be included
+	Tags         tilda.TagOptions // Options of script and stylesheet tags
+	Body         string           // Body HTML code (e.g. #allrecords element)
+}
+
+// PageDocument returns document of the page for export with SEO settings of the page and the project
+func PageDocument(page tilda.PageExport, project tilda.ProjectInfo) Document {
+	return Document{
+		Title:        page.Title,
+		Description:  page.Description,
+		Image:        firstNonEmpty(page.Img, page.FeatureImg),
+		CanonicalURL: CanonicalURL(project, page.ID, page.Alias, page.Filename),
+		Favicon:      Favicon(project),
+		Headcode:     project.Headcode,
+		CSS:          page.CSS,
+		JS:           page.JS,
+		Body:         page.HTML,
+	}
+}
+
+// BodyPageDocument returns document of the page with body HTML code (GetPage) with SEO settings of the page and the project
+func BodyPageDocument(page tilda.Page, project tilda.ProjectInfo) Document {
+	doc := Document{
+		Title:        page.Title,
+		Description:  page.Description,
+		Image:        firstNonEmpty(page.Img, page.FeatureImg),
+		CanonicalURL: CanonicalURL(project, page.ID, page.Alias, page.Filename),
+		Favicon:      Favicon(project),
+		Headcode:     project.Headcode,
+		CSS:          make([]tilda.CSS, 0, len(page.CSS)),
+		JS:           make([]tilda.JS, 0, len(page.JS)),
+		Body:         page.HTML,
+	}
+	for _, u := range page.CSS {
+		doc.CSS = append(doc.CSS, tilda.CSS{From: u, To: tilda.AssetFilename(u)})
+	}
+	for _, u := range page.JS {
+		doc.JS = append(doc.JS, tilda.JS{From: u, To: tilda.AssetFilename(u)})
+	}
+
+	return doc
+}
+
+// CanonicalURL returns URL of the page on the project domain: root for index page, alias or filename for other pages.
+// Empty string is returned if the project has no domain.
+func CanonicalURL(project tilda.ProjectInfo, pageID, alias, filename string) string {
+	baseURL := projectBaseURL(project)
+	if baseURL == "" {
+		return ""
+	}
+
+	return pageURL(baseURL, project, pageID, alias, filename)
+}
+
+// projectBaseURL returns URL of the project domain without trailing slash or empty string if the project has no domain
+func projectBaseURL(project tilda.ProjectInfo) string {
+	domain := strings.TrimSuffix(firstNonEmpty(project.CustomDomain, project.URL), "/")
+	if domain != "" && !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	return domain
+}
+
+// pageURL returns URL of the page under baseURL
+func pageURL(baseURL string, project tilda.ProjectInfo, pageID, alias, filename string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	switch {
+	case isPageID(project.IndexpageID) && pageID == project.IndexpageID:
+		return baseURL + "/"
+	case strings.Trim(alias, "/") != "":
+		return baseURL + "/" + strings.Trim(alias, "/")
+	case filename != "":
+		return baseURL + "/" + filename
+	}
+
+	return baseURL + "/page" + pageID + ".html"
+}
+
+// Favicon returns URL of the project favicon or empty string if the project has no favicon
+func Favicon(project tilda.ProjectInfo) string {
+	for _, img := range project.Images {
+		if strings.Contains(path.Base(img.To), "favicon") {
+			return img.From
+		}
+	}
+
+	return ""
+}
+
+// Render renders HTML5 document: meta tags, stylesheets, scripts and head code in head element, body HTML code in body element
+func (d Document) Render() string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n")
+	if d.Lang != "" {
+		b.WriteString(`<html lang="` + template.HTMLEscapeString(d.Lang) + `">` + "\n")
+	} else {
+		b.WriteString("<html>\n")
+	}
+
+	b.WriteString("<head>\n")
+	b.WriteString(`<meta charset="utf-8">` + "\n")
+	b.WriteString(`<meta name="viewport" content="width=device-width, initial-scale=1.0">` + "\n")
+	b.WriteString("<title>" + template.HTMLEscapeString(d.Title) + "</title>\n")
+	writeMeta(&b, "name", "description", d.Description)
+	writeMeta(&b, "property", "og:url", d.CanonicalURL)
+	writeMeta(&b, "property", "og:title", d.Title)
+	writeMeta(&b, "property", "og:description", d.Description)
+	writeMeta(&b, "property", "og:type", "website")
+	writeMeta(&b, "property", "og:image", d.Image)
+	if d.CanonicalURL != "" {
+		b.WriteString(`<link rel="canonical" href="` + template.HTMLEscapeString(d.CanonicalURL) + `">` + "\n")
+	}
+	if d.Favicon != "" {
+		b.WriteString(`<link rel="shortcut icon" href="` + template.HTMLEscapeString(d.Favicon) + `" type="image/x-icon">` + "\n")
+	}
+	if styles := tilda.RenderStylesheets(d.CSS, d.Tags); styles != "" {
+		b.WriteString(string(styles) + "\n")
+	}
+	if scripts := tilda.RenderScripts(d.JS, d.Tags); scripts != "" {
+		b.WriteString(string(scripts) + "\n")
+	}
+	if headcode := strings.TrimSpace(d.Headcode); headcode != "" {
+		b.WriteString(headcode + "\n")
+	}
+	b.WriteString("</head>\n")
+
+	b.WriteString(`<body class="t-body" style="margin:0;">` + "\n")
+	b.WriteString(d.Body + "\n")
+	b.WriteString("</body>\n</html>\n")
+
+	return b.String()
+}
+
+func writeMeta(b *strings.Builder, attr, name, content string) {
+	if content == "" {
+		return
+	}
+
+	b.WriteString(`<meta ` + attr + `="` + name + `" content="` + template.HTMLEscapeString(content) + `">` + "\n")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
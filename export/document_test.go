@@ -0,0 +1,96 @@
+package export
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestPageDocument_Render(t *testing.T) {
+	project := tilda.ProjectInfo{
+		ID:           "54321",
+		CustomDomain: "example.com",
+		IndexpageID:  "1",
+		Headcode:     "<script>window.dataLayer = [];</script>\n",
+		Images: []tilda.Image{
+			{From: "https://static.tildacdn.com/img/logo.png", To: "logo.png"},
+			{From: "https://static.tildacdn.com/img/tildafavicon.ico", To: "tildafavicon.ico"},
+		},
+	}
+	page := tilda.PageExport{
+		ID:          "2",
+		Title:       `Blog & "news"`,
+		Description: "Latest posts",
+		FeatureImg:  "https://static.tildacdn.com/img/feature.jpg",
+		Alias:       "blog",
+		HTML:        `<div id="allrecords"></div>`,
+		JS:          []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js", Attrs: []string{"defer"}}},
+		CSS:         []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css", To: "tilda-grid-3.0.min.css"}},
+	}
+
+	doc := PageDocument(page, project)
+	doc.Lang = "en"
+	doc.Tags = tilda.TagOptions{Local: true, BasePath: "/static/"}
+
+	assert.Equal(t, `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Blog &amp; &#34;news&#34;</title>
+<meta name="description" content="Latest posts">
+<meta property="og:url" content="https://example.com/blog">
+<meta property="og:title" content="Blog &amp; &#34;news&#34;">
+<meta property="og:description" content="Latest posts">
+<meta property="og:type" content="website">
+<meta property="og:image" content="https://static.tildacdn.com/img/feature.jpg">
+<link rel="canonical" href="https://example.com/blog">
+<link rel="shortcut icon" href="https://static.tildacdn.com/img/tildafavicon.ico" type="image/x-icon">
+<link rel="stylesheet" href="/static/tilda-grid-3.0.min.css">
+<script src="/static/tilda-scripts-3.0.min.js" defer></script>
+<script>window.dataLayer = [];</script>
+</head>
+<body class="t-body" style="margin:0;">
+<div id="allrecords"></div>
+</body>
+</html>
+`, doc.Render())
+}
+
+func TestBodyPageDocument(t *testing.T) {
+	doc := BodyPageDocument(tilda.Page{
+		ID:    "1",
+		Title: "Home",
+		Img:   "https://static.tildacdn.com/img/social.jpg",
+		JS:    []string{"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js?t=1"},
+		CSS:   []string{"https://static.tildacdn.com/css/tilda-grid-3.0.min.css"},
+	}, tilda.ProjectInfo{IndexpageID: "1", URL: "http://project54321.tilda.ws/"})
+
+	assert.Equal(t, "http://project54321.tilda.ws/", doc.CanonicalURL)
+	assert.Equal(t, "https://static.tildacdn.com/img/social.jpg", doc.Image)
+	assert.Equal(t, []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js?t=1", To: "tilda-scripts-3.0.min.js"}}, doc.JS)
+	assert.Equal(t, []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css", To: "tilda-grid-3.0.min.css"}}, doc.CSS)
+}
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  tilda.ProjectInfo
+		pageID   string
+		alias    string
+		filename string
+		want     string
+	}{
+		{name: "index page", project: tilda.ProjectInfo{CustomDomain: "example.com", IndexpageID: "1"}, pageID: "1", alias: "home", want: "https://example.com/"},
+		{name: "alias", project: tilda.ProjectInfo{CustomDomain: "example.com"}, pageID: "2", alias: "/blog/", want: "https://example.com/blog"},
+		{name: "filename", project: tilda.ProjectInfo{CustomDomain: "example.com"}, pageID: "2", filename: "page2.html", want: "https://example.com/page2.html"},
+		{name: "no filename", project: tilda.ProjectInfo{CustomDomain: "example.com"}, pageID: "2", want: "https://example.com/page2.html"},
+		{name: "no domain", pageID: "2", alias: "blog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CanonicalURL(tt.project, tt.pageID, tt.alias, tt.filename))
+		})
+	}
+}
@@ -0,0 +1,544 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// Default directories of assets used when export paths are not set in the project settings
+const (
+	DefaultImgPath = "images"
+	DefaultJSPath  = "js"
+	DefaultCSSPath = "css"
+)
+
+// Asset represents file that should be downloaded from Tilda
+type Asset struct {
+	URL  string `json:"url"`  // Source URL
+	Path string `json:"path"` // Path of the file in the storage
+}
+
+// DownloadResult represents information about downloaded asset
+type DownloadResult struct {
+	Asset
+	Size            int64          `json:"size"`                       // Size of the file in bytes
+	SHA256          string         `json:"sha256"`                     // Hex-encoded SHA-256 checksum of the file
+	ContentType     string         `json:"content_type"`               // MIME type of the file detected by extension or content
+	Integrity       string         `json:"integrity,omitempty"`        // Subresource Integrity hash of scripts and styles (empty for other assets)
+	SourceIntegrity string         `json:"source_integrity,omitempty"` // Subresource Integrity hash of the response if it was transformed (e.g. fingerprinted styles)
+	Width           int            `json:"width,omitempty"`            // Width of optimized image in pixels (0 for other assets)
+	OriginalSize    int64          `json:"original_size,omitempty"`    // Size of the image before optimization (0 for other assets)
+	Variants        []ImageVariant `json:"variants,omitempty"`         // Downscaled variants of optimized image
+	FingerprintPath string         `json:"fingerprint_path,omitempty"` // Path of content-hashed file written instead of Path when fingerprinting is enabled
+	ETag            string         `json:"etag,omitempty"`             // ETag of the response, used for conditional requests
+	LastModified    string         `json:"last_modified,omitempty"`    // Last-Modified of the response, used for conditional requests
+	Unchanged       bool           `json:"-"`                          // The file already existed in the storage with the same content and was not written
+}
+
+// StoragePath returns path of the file written into the storage
+func (r DownloadResult) StoragePath() string {
+	if r.FingerprintPath != "" {
+		return r.FingerprintPath
+	}
+
+	return r.Path
+}
+
+// AssetError represents error of downloading single asset
+type AssetError struct {
+	URL string // Source URL of asset that was not downloaded
+	Err error  // Error
+}
+
+// Error() converts error to string
+func (e *AssetError) Error() string {
+	return fmt.Sprintf("asset %s: %s", e.URL, e.Err.Error())
+}
+
+func (e *AssetError) Unwrap() error {
+	return e.Err
+}
+
+// Downloader downloads page assets into the storage
+type Downloader struct {
+	storage     Storage
+	httpClient  *http.Client
+	concurrency int
+	retries     int
+	retryDelay  time.Duration
+	store       *ContentStore
+	fingerprint bool
+	previous    map[string]DownloadResult           // Results of the previous download by asset path
+	transform   func(Asset, []byte) ([]byte, error) // Changes asset content before it is written
+}
+
+// NewDownloader creates new downloader writing assets into the storage
+func NewDownloader(storage Storage, options ...func(*Downloader)) *Downloader {
+	downloader := &Downloader{
+		storage:     storage,
+		httpClient:  http.DefaultClient,
+		concurrency: 4,
+		retries:     3,
+		retryDelay:  time.Second,
+	}
+
+	for _, o := range options {
+		o(downloader)
+	}
+
+	return downloader
+}
+
+// WithCustomHttpClient option allows to set custom http client
+func WithCustomHttpClient(httpClient *http.Client) func(*Downloader) {
+	return func(d *Downloader) {
+		d.httpClient = httpClient
+	}
+}
+
+// WithConcurrency option allows to set max number of simultaneous downloads
+func WithConcurrency(concurrency int) func(*Downloader) {
+	return func(d *Downloader) {
+		d.concurrency = max(concurrency, 1)
+	}
+}
+
+// WithRetries option allows to set the number of retries of failed download and the delay before the first retry
+// (the delay grows with every attempt)
+func WithRetries(retries int, delay time.Duration) func(*Downloader) {
+	return func(d *Downloader) {
+		d.retries = max(retries, 0)
+		d.retryDelay = delay
+	}
+}
+
+// WithContentStore option allows to keep downloaded assets in the content store shared across pages and projects.
+// Assets already downloaded into the store from the same URL are copied from the store instead of downloading.
+// If the downloader storage is nil, assets are written into the store only.
+func WithContentStore(store *ContentStore) func(*Downloader) {
+	return func(d *Downloader) {
+		d.store = store
+	}
+}
+
+// WithFingerprints option allows to write assets under content-hashed names (see FingerprintPath),
+// so they can be cached forever
+func WithFingerprints() func(*Downloader) {
+	return func(d *Downloader) {
+		d.fingerprint = true
+	}
+}
+
+// WithPreviousResults option allows to skip assets that were not changed since the previous download.
+// Assets with ETag or Last-Modified in previous results are requested with If-None-Match or If-Modified-Since,
+// and the previous result is returned if Tilda responds with 304 Not Modified and the file in the storage
+// still has the previous checksum. Other assets are downloaded and compared by checksum.
+func WithPreviousResults(results []DownloadResult) func(*Downloader) {
+	return func(d *Downloader) {
+		d.previous = make(map[string]DownloadResult, len(results))
+		for _, result := range results {
+			d.previous[result.Path] = result
+		}
+	}
+}
+
+// PageAssets returns images, scripts and styles of the page placed under export paths of the page
+// or under default directories if export paths are not set
+func PageAssets(page tilda.PageExport) []Asset {
+	assets := make([]Asset, 0, len(page.Images)+len(page.JS)+len(page.CSS))
+	for _, img := range page.Images {
+		assets = append(assets, Asset{URL: img.From, Path: path.Join(exportDir(page.ExportImgPath, DefaultImgPath), img.To)})
+	}
+	for _, js := range page.JS {
+		assets = append(assets, Asset{URL: js.From, Path: path.Join(exportDir(page.ExportJSPath, DefaultJSPath), js.To)})
+	}
+	for _, css := range page.CSS {
+		assets = append(assets, Asset{URL: css.From, Path: path.Join(exportDir(page.ExportCSSPath, DefaultCSSPath), css.To)})
+	}
+
+	return assets
+}
+
+// exportDir converts export path from the project settings (e.g. "/images" or "https://example.com/images")
+// to the directory in the storage
+func exportDir(exportPath, defaultDir string) string {
+	if u, err := url.Parse(exportPath); err == nil && u.Host != "" {
+		exportPath = u.Path
+	}
+
+	exportPath = strings.Trim(path.Clean("/"+exportPath), "/")
+	if exportPath == "" {
+		return defaultDir
+	}
+
+	return exportPath
+}
+
+// DownloadPage downloads all assets of the page. With content store the page references downloaded assets in the store.
+func (d *Downloader) DownloadPage(ctx context.Context, page tilda.PageExport) ([]DownloadResult, error) {
+	results, err := d.Download(ctx, PageAssets(page))
+	if d.store != nil {
+		d.store.SetRefs(PageOwner(page.ProjectID, page.ID), resultSums(results))
+	}
+
+	return results, err
+}
+
+// Download downloads assets concurrently. Assets with the same path are downloaded once.
+// Failed assets are skipped and returned joined as *AssetError, so the result contains every downloaded asset.
+func (d *Downloader) Download(ctx context.Context, assets []Asset) ([]DownloadResult, error) {
+	unique := make([]Asset, 0, len(assets))
+	seen := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		if seen[asset.Path] {
+			continue
+		}
+
+		seen[asset.Path] = true
+		unique = append(unique, asset)
+	}
+
+	results := make([]DownloadResult, len(unique))
+	errs := make([]error, len(unique))
+	sem := make(chan struct{}, max(d.concurrency, 1))
+	var wg sync.WaitGroup
+	for i, asset := range unique {
+		select {
+		case <-ctx.Done():
+			errs[i] = &AssetError{URL: asset.URL, Err: ctx.Err()}
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, asset Asset) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := d.downloadAsset(ctx, asset)
+			if err != nil {
+				errs[i] = &AssetError{URL: asset.URL, Err: err}
+				return
+			}
+
+			results[i] = result
+		}(i, asset)
+	}
+	wg.Wait()
+
+	downloaded := make([]DownloadResult, 0, len(unique))
+	for i := range unique {
+		if errs[i] == nil {
+			downloaded = append(downloaded, results[i])
+		}
+	}
+
+	return downloaded, errors.Join(errs...)
+}
+
+func (d *Downloader) downloadAsset(ctx context.Context, asset Asset) (DownloadResult, error) {
+	prev := d.revalidated(ctx, asset)
+	fetched, sum, err := d.content(ctx, asset.URL, prev)
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	if fetched.notModified {
+		result := *prev
+		result.Unchanged = true
+		return result, nil
+	}
+
+	body := fetched.body
+
+	if d.transform != nil {
+		if body, err = d.transform(asset, body); err != nil {
+			return DownloadResult{}, fmt.Errorf("transform content: %w", err)
+		}
+
+		if d.store != nil {
+			// Keep transformed content in the store, so that the blob referenced by the checksum exists
+			if sum, err = d.store.Put(ctx, "", body); err != nil {
+				return DownloadResult{}, fmt.Errorf("put content: %w", err)
+			}
+		} else {
+			hash := sha256.Sum256(body)
+			sum = hex.EncodeToString(hash[:])
+		}
+	}
+
+	result := DownloadResult{
+		Asset:        asset,
+		Size:         int64(len(body)),
+		SHA256:       sum,
+		ContentType:  contentType(asset.Path, body),
+		ETag:         fetched.etag,
+		LastModified: fetched.lastModified,
+	}
+	if isSubresource(asset.Path) {
+		result.Integrity = tilda.IntegrityHash(body)
+		if !bytes.Equal(body, fetched.body) {
+			result.SourceIntegrity = tilda.IntegrityHash(fetched.body)
+		}
+	}
+	if d.fingerprint {
+		result.FingerprintPath = FingerprintPath(asset.Path, sum)
+	}
+
+	if d.storage == nil {
+		return result, nil
+	}
+
+	existing, err := checksum(ctx, d.storage, result.StoragePath())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return DownloadResult{}, fmt.Errorf("check existing file: %w", err)
+	}
+
+	if existing == result.SHA256 {
+		result.Unchanged = true
+		return result, nil
+	}
+
+	if err := d.storage.Put(ctx, result.StoragePath(), bytes.NewReader(body)); err != nil {
+		return DownloadResult{}, fmt.Errorf("put file: %w", err)
+	}
+
+	return result, nil
+}
+
+// revalidated returns the previous result of the asset if it can be requested conditionally: the previous response
+// had validators and the file written then is still in the storage. Assets of the content store are never revalidated.
+func (d *Downloader) revalidated(ctx context.Context, asset Asset) *DownloadResult {
+	prev, ok := d.previous[asset.Path]
+	if !ok || prev.URL != asset.URL || prev.ETag == "" && prev.LastModified == "" ||
+		d.store != nil || d.storage == nil || d.fingerprint != (prev.FingerprintPath != "") {
+		return nil
+	}
+
+	if existing, err := checksum(ctx, d.storage, prev.StoragePath()); err != nil || existing != prev.SHA256 {
+		return nil
+	}
+
+	return &prev
+}
+
+// fetchedContent represents response to asset request
+type fetchedContent struct {
+	body         []byte
+	etag         string
+	lastModified string
+	notModified  bool // Conditional request was answered with 304 Not Modified, body is empty
+}
+
+// content returns asset content and its hex-encoded SHA-256 checksum. The asset is requested conditionally
+// if prev is not nil.
+func (d *Downloader) content(ctx context.Context, assetURL string, prev *DownloadResult) (fetchedContent, string, error) {
+	if d.store == nil {
+		fetched, err := d.fetch(ctx, assetURL, prev)
+		if err != nil || fetched.notModified {
+			return fetched, "", err
+		}
+
+		sum := sha256.Sum256(fetched.body)
+
+		return fetched, hex.EncodeToString(sum[:]), nil
+	}
+
+	if sum, ok := d.store.Lookup(assetURL); ok {
+		if body, err := d.storeContent(ctx, sum); err == nil {
+			return fetchedContent{body: body}, sum, nil
+		}
+	}
+
+	// Content saved by previous stores is reused only if the source is not modified since then
+	source, ok := d.store.source(assetURL)
+	var validators *DownloadResult
+	if ok && (source.ETag != "" || source.LastModified != "") {
+		validators = &DownloadResult{ETag: source.ETag, LastModified: source.LastModified}
+	}
+
+	fetched, err := d.fetch(ctx, assetURL, validators)
+	if err != nil {
+		return fetchedContent{}, "", err
+	}
+	if fetched.notModified {
+		body, err := d.storeContent(ctx, source.SHA256)
+		if err == nil {
+			d.store.revalidated(assetURL)
+			return fetchedContent{body: body, etag: source.ETag, lastModified: source.LastModified}, source.SHA256, nil
+		}
+
+		// The blob is gone, so the content is requested again
+		if fetched, err = d.fetch(ctx, assetURL, nil); err != nil {
+			return fetchedContent{}, "", err
+		}
+	}
+
+	sum, err := d.store.putSource(ctx, assetURL, fetched.body, fetched.etag, fetched.lastModified)
+	if err != nil {
+		return fetchedContent{}, "", fmt.Errorf("put content: %w", err)
+	}
+
+	return fetched, sum, nil
+}
+
+// storeContent reads the blob of the content store
+func (d *Downloader) storeContent(ctx context.Context, sum string) ([]byte, error) {
+	rc, err := d.store.Open(ctx, sum)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func (d *Downloader) fetch(ctx context.Context, assetURL string, prev *DownloadResult) (fetchedContent, error) {
+	var lastErr error
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(d.retryDelay * time.Duration(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fetchedContent{}, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		fetched, retry, err := d.fetchOnce(ctx, assetURL, prev)
+		if err == nil {
+			return fetched, nil
+		}
+
+		lastErr = err
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return fetchedContent{}, lastErr
+}
+
+func (d *Downloader) fetchOnce(ctx context.Context, assetURL string, prev *DownloadResult) (fetchedContent, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return fetchedContent{}, false, fmt.Errorf("create request: %w", err)
+	}
+	if prev != nil {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fetchedContent{}, true, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && prev != nil {
+		return fetchedContent{notModified: true}, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return fetchedContent{}, retry, fmt.Errorf("response status code is %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fetchedContent{}, true, fmt.Errorf("read response body: %w", err)
+	}
+
+	return fetchedContent{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, false, nil
+}
+
+// assetContentTypes are MIME types of usual assets that don't depend on mime.types files of the system
+var assetContentTypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".html":  "text/html; charset=utf-8",
+	".svg":   "image/svg+xml",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/x-icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+	".pdf":   "application/pdf",
+}
+
+// contentType returns MIME type of the file by its extension or by its content if the extension is unknown
+func contentType(name string, body []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ct, ok := assetContentTypes[ext]; ok {
+		return ct
+	}
+
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+
+	return http.DetectContentType(body)
+}
+
+// resultSums returns checksums of downloaded assets
+func resultSums(results []DownloadResult) []string {
+	sums := make([]string, 0, len(results))
+	for _, result := range results {
+		sums = append(sums, result.SHA256)
+	}
+
+	return sums
+}
+
+// checksum returns hex-encoded SHA-256 checksum of the file in the storage
+func checksum(ctx context.Context, storage Storage, name string) (string, error) {
+	if c, ok := storage.(checksumStorage); ok {
+		return c.Checksum(ctx, name)
+	}
+
+	rc, err := storage.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", fmt.Errorf("read %s: %w", name, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,226 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPageAssets(t *testing.T) {
+	tests := []struct {
+		name string
+		page tilda.PageExport
+		want []Asset
+	}{
+		{
+			name: "default paths",
+			page: tilda.PageExport{
+				Images: []tilda.Image{{From: "https://static.tildacdn.com/img/tildacopy.png", To: "tildacopy.png"}},
+				JS:     []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"}},
+				CSS:    []tilda.CSS{{From: "https://static.tildacdn.com/css/fonts-tildasans.css", To: "fonts-tildasans.css"}},
+			},
+			want: []Asset{
+				{URL: "https://static.tildacdn.com/img/tildacopy.png", Path: "images/tildacopy.png"},
+				{URL: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", Path: "js/tilda-scripts-3.0.min.js"},
+				{URL: "https://static.tildacdn.com/css/fonts-tildasans.css", Path: "css/fonts-tildasans.css"},
+			},
+		}, {
+			name: "export paths",
+			page: tilda.PageExport{
+				ExportImgPath: "/static/img",
+				ExportJSPath:  "https://example.com/static/js/",
+				ExportCSSPath: "static/../styles",
+				Images:        []tilda.Image{{From: "https://static.tildacdn.com/img/tildacopy.png", To: "tildacopy.png"}},
+				JS:            []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"}},
+				CSS:           []tilda.CSS{{From: "https://static.tildacdn.com/css/fonts-tildasans.css", To: "fonts-tildasans.css"}},
+			},
+			want: []Asset{
+				{URL: "https://static.tildacdn.com/img/tildacopy.png", Path: "static/img/tildacopy.png"},
+				{URL: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", Path: "static/js/tilda-scripts-3.0.min.js"},
+				{URL: "https://static.tildacdn.com/css/fonts-tildasans.css", Path: "styles/fonts-tildasans.css"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PageAssets(tt.page))
+		})
+	}
+}
+
+func TestDownloader_DownloadPage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/img/tildacopy.png",
+		httpmock.NewStringResponder(http.StatusOK, "png"))
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/css/fonts-tildasans.css",
+		httpmock.NewStringResponder(http.StatusOK, "body{}"))
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js",
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(http.StatusBadGateway, ""),
+			httpmock.NewStringResponse(http.StatusOK, "js"),
+		}))
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/js/missing.js",
+		httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "css"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "css", "fonts-tildasans.css"), []byte("body{}"), 0o644))
+
+	downloader := NewDownloader(NewDirStorage(root), WithConcurrency(2), WithRetries(2, time.Millisecond))
+	got, err := downloader.DownloadPage(context.Background(), tilda.PageExport{
+		Images: []tilda.Image{
+			{From: "https://static.tildacdn.com/img/tildacopy.png", To: "tildacopy.png"},
+			{From: "https://static.tildacdn.com/img/tildacopy.png", To: "tildacopy.png"},
+		},
+		JS: []tilda.JS{
+			{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"},
+			{From: "https://static.tildacdn.com/js/missing.js", To: "missing.js"},
+		},
+		CSS: []tilda.CSS{{From: "https://static.tildacdn.com/css/fonts-tildasans.css", To: "fonts-tildasans.css"}},
+	})
+
+	var assetErr *AssetError
+	if assert.True(t, errors.As(err, &assetErr)) {
+		assert.Equal(t, "https://static.tildacdn.com/js/missing.js", assetErr.URL)
+	}
+
+	sort.Slice(got, func(i, j int) bool {
+		return got[i].Path < got[j].Path
+	})
+	assert.Equal(t, []DownloadResult{
+		{
+			Asset:       Asset{URL: "https://static.tildacdn.com/css/fonts-tildasans.css", Path: "css/fonts-tildasans.css"},
+			Size:        6,
+			SHA256:      sha256Hex("body{}"),
+			ContentType: "text/css; charset=utf-8",
+			Integrity:   tilda.IntegrityHash([]byte("body{}")),
+			Unchanged:   true,
+		}, {
+			Asset:       Asset{URL: "https://static.tildacdn.com/img/tildacopy.png", Path: "images/tildacopy.png"},
+			Size:        3,
+			SHA256:      sha256Hex("png"),
+			ContentType: "image/png",
+		}, {
+			Asset:       Asset{URL: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", Path: "js/tilda-scripts-3.0.min.js"},
+			Size:        2,
+			SHA256:      sha256Hex("js"),
+			ContentType: "text/javascript; charset=utf-8",
+			Integrity:   tilda.IntegrityHash([]byte("js")),
+		},
+	}, got)
+
+	bts, err := os.ReadFile(filepath.Join(root, "js", "tilda-scripts-3.0.min.js"))
+	assert.NoError(t, err)
+	assert.Equal(t, "js", string(bts))
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["GET https://static.tildacdn.com/img/tildacopy.png"])
+	assert.Equal(t, 2, calls["GET https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"])
+	assert.Equal(t, 1, calls["GET https://static.tildacdn.com/js/missing.js"])
+}
+
+func TestDownloader_Download_Canceled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/img/tildacopy.png",
+		httpmock.NewStringResponder(http.StatusServiceUnavailable, ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	downloader := NewDownloader(NewDirStorage(t.TempDir()), WithRetries(5, time.Second))
+	got, err := downloader.Download(ctx, []Asset{{URL: "https://static.tildacdn.com/img/tildacopy.png", Path: "images/tildacopy.png"}})
+	assert.Empty(t, got)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDownloader_Download_Conditional(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var bodies atomic.Int32
+	conditional := func(body, validator, value, condition string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(condition) == value {
+				return httpmock.NewStringResponse(http.StatusNotModified, ""), nil
+			}
+
+			bodies.Add(1)
+			resp := httpmock.NewStringResponse(http.StatusOK, body)
+			if validator != "" {
+				resp.Header.Set(validator, value)
+			}
+
+			return resp, nil
+		}
+	}
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/img/photo.jpg",
+		conditional("photo", "ETag", `"v1"`, "If-None-Match"))
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js",
+		conditional("scripts", "Last-Modified", "Sun, 15 Dec 2024 13:20:30 GMT", "If-Modified-Since"))
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/css/fonts-tildasans.css",
+		conditional("fonts", "", "never", "If-None-Match"))
+
+	assets := []Asset{
+		{URL: "https://static.tildacdn.com/img/photo.jpg", Path: "images/photo.jpg"},
+		{URL: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", Path: "js/tilda-scripts-3.0.min.js"},
+		{URL: "https://static.tildacdn.com/css/fonts-tildasans.css", Path: "css/fonts-tildasans.css"},
+	}
+	root := t.TempDir()
+	ctx := context.Background()
+
+	first, err := NewDownloader(NewDirStorage(root)).Download(ctx, assets)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), bodies.Load())
+	if assert.Len(t, first, 3) {
+		assert.Equal(t, `"v1"`, first[0].ETag)
+		assert.Equal(t, "Sun, 15 Dec 2024 13:20:30 GMT", first[1].LastModified)
+	}
+
+	// Assets with validators are not downloaded again, assets without them are compared by checksum
+	bodies.Store(0)
+	second, err := NewDownloader(NewDirStorage(root), WithPreviousResults(first)).Download(ctx, assets)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), bodies.Load())
+	if assert.Len(t, second, 3) {
+		for i := range second {
+			assert.True(t, second[i].Unchanged, second[i].Path)
+			second[i].Unchanged = false
+		}
+		assert.Equal(t, first, second)
+	}
+
+	// The file changed in the storage is downloaded again
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "images", "photo.jpg"), []byte("changed"), 0o644))
+	bodies.Store(0)
+	third, err := NewDownloader(NewDirStorage(root), WithPreviousResults(first)).Download(ctx, assets[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), bodies.Load())
+	if assert.Len(t, third, 1) {
+		assert.False(t, third[0].Unchanged)
+	}
+	bts, err := os.ReadFile(filepath.Join(root, "images", "photo.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "photo", string(bts))
+}
@@ -0,0 +1,17 @@
+package export
+
+import (
+	"path"
+	"strings"
+)
+
+// FingerprintPath returns path of the file with content checksum inserted before extension
+// (e.g. css/tilda-grid-3.0.min.0123456789abcdef.css)
+func FingerprintPath(name, sum string) string {
+	ext := path.Ext(name)
+	if len(sum) > 16 {
+		sum = sum[:16]
+	}
+
+	return strings.TrimSuffix(name, ext) + "." + sum + ext
+}
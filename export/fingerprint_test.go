@@ -0,0 +1,116 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprintPath(t *testing.T) {
+	tests := []struct {
+		name string
+		sum  string
+		want string
+	}{
+		{name: "css/tilda-grid-3.0.min.css", sum: sha256Hex("grid"), want: "css/tilda-grid-3.0.min." + sha256Hex("grid")[:16] + ".css"},
+		{name: "images/photo", sum: "0123abcd", want: "images/photo.0123abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FingerprintPath(tt.name, tt.sum))
+		})
+	}
+}
+
+func TestDownloader_WithFingerprints(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerAsset("https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", "scripts")
+
+	root := t.TempDir()
+	results, err := NewDownloader(NewDirStorage(root), WithFingerprints()).Download(context.Background(), []Asset{
+		{URL: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", Path: "js/tilda-scripts-3.0.min.js"},
+	})
+	assert.NoError(t, err)
+
+	name := "js/tilda-scripts-3.0.min." + sha256Hex("scripts")[:16] + ".js"
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, name, results[0].FingerprintPath)
+		assert.Equal(t, name, results[0].StoragePath())
+	}
+
+	bts, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+	assert.NoError(t, err)
+	assert.Equal(t, "scripts", string(bts))
+
+	_, err = os.Stat(filepath.Join(root, "js", "tilda-scripts-3.0.min.js"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestProject_WithAssetFingerprints(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+	styles := `body{background:url("https://static.tildacdn.com/img/photo1.jpg?t=1734259633")}`
+	registerAsset("https://static.tildacdn.com/css/fonts-tildasans.css", styles)
+
+	root := t.TempDir()
+	storage := NewDirStorage(root)
+	result, err := Project(context.Background(), newTestClient(), "54321", storage, WithAssetFingerprints())
+	assert.NoError(t, err)
+
+	photo1 := "images/photo1." + sha256Hex("photo 1")[:16] + ".jpg"
+	photo2 := "images/photo2." + sha256Hex("photo 2")[:16] + ".jpg"
+	rewrittenStyles := `body{background:url("../` + photo1 + `")}`
+	css := "css/fonts-tildasans." + sha256Hex(rewrittenStyles)[:16] + ".css"
+	want := map[string]string{
+		"images/tildafavicon.ico":     "images/tildafavicon." + sha256Hex("favicon")[:16] + ".ico",
+		"images/photo1.jpg":           photo1,
+		"images/photo2.jpg":           photo2,
+		"js/tilda-scripts-3.0.min.js": "js/tilda-scripts-3.0.min." + sha256Hex("scripts")[:16] + ".js",
+		"css/fonts-tildasans.css":     css,
+	}
+	assert.Equal(t, want, result.Manifest.Fingerprints())
+
+	bts, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(css)))
+	assert.NoError(t, err)
+	assert.Equal(t, rewrittenStyles, string(bts))
+
+	bts, err = os.ReadFile(filepath.Join(root, "blog", "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<!DOCTYPE html><html><head><link rel="stylesheet" href="../`+css+`"></head><body><div id="allrecords" data-tilda-page-id="2"><img src="../`+photo2+`"></div></body></html>`, string(bts))
+
+	bts, err = os.ReadFile(filepath.Join(root, AssetMapFilename))
+	assert.NoError(t, err)
+	var assetMap map[string]string
+	assert.NoError(t, json.Unmarshal(bts, &assetMap))
+	assert.Equal(t, want, assetMap)
+
+	for original := range want {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(original)))
+		assert.ErrorIs(t, err, os.ErrNotExist, original)
+	}
+
+	// Export without fingerprints rewrites all pages and removes fingerprinted files
+	httpmock.ZeroCallCounters()
+	result, err = Project(context.Background(), newTestClient(), "54321", storage)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+testAPIBaseURL+"/v1/getpagefullexport/?pageid=1&publickey=public&secretkey=secret"])
+	assert.Empty(t, result.Manifest.Fingerprints())
+	assert.Contains(t, result.Removed, AssetMapFilename)
+	assert.Contains(t, result.Removed, css)
+
+	for original, fingerprinted := range want {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(original)))
+		assert.NoError(t, err, original)
+		_, err = os.Stat(filepath.Join(root, filepath.FromSlash(fingerprinted)))
+		assert.ErrorIs(t, err, os.ErrNotExist, fingerprinted)
+	}
+
+}
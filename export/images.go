@@ -0,0 +1,392 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/net/html"
+)
+
+// DefaultImageQuality is JPEG quality used if ImageOptions.Quality is not set
+const DefaultImageQuality = 82
+
+// maxImagePixels limits size of decoded images, larger images (e.g. decompression bombs) are written as is
+const maxImagePixels = 50_000_000
+
+// ImageOptions configures optimization of exported JPEG and PNG images
+type ImageOptions struct {
+	MaxWidth  int   // Wider images are downscaled preserving aspect ratio (0 means no limit)
+	MaxHeight int   // Higher images are downscaled preserving aspect ratio (0 means no limit)
+	Quality   int   // JPEG quality from 1 to 100 (DefaultImageQuality if 0)
+	Widths    []int // Widths of responsive variants listed in srcset of img elements (only widths less than image width are used)
+}
+
+// ImageVariant represents downscaled copy of the image written for srcset
+type ImageVariant struct {
+	Path   string `json:"path"`   // Path of the file in the storage
+	Width  int    `json:"width"`  // Width in pixels
+	Size   int64  `json:"size"`   // Size of the file in bytes
+	SHA256 string `json:"sha256"` // Hex-encoded SHA-256 checksum of the file
+}
+
+// ImageVariantPath returns path of the image variant with the width inserted before extension
+// (e.g. images/photo.w480.jpg)
+func ImageVariantPath(name string, width int) string {
+	ext := path.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + ".w" + strconv.Itoa(width) + ext
+}
+
+// WithImageOptimization option allows to re-encode and downscale JPEG and PNG images and write
+// their responsive variants. Savings are recorded in the manifest (see ManifestAsset.OriginalSize).
+func WithImageOptimization(opts ImageOptions) func(*Exporter) {
+	return func(e *Exporter) {
+		e.images = &opts
+	}
+}
+
+// stateKey returns options recorded in export state, so that pages are exported again when options change
+func (o *ImageOptions) stateKey() string {
+	if o == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d:%d:%d:%v", o.MaxWidth, o.MaxHeight, o.Quality, o.Widths)
+}
+
+// optimizedImage represents image re-encoded during export
+type optimizedImage struct {
+	originalSize int64
+	width        int
+	variants     []encodedVariant
+}
+
+type encodedVariant struct {
+	width int
+	body  []byte
+}
+
+// imageOptimizer re-encodes images downloaded by the exporter and keeps their variants until they are written
+type imageOptimizer struct {
+	opts ImageOptions
+
+	mu     sync.Mutex
+	images map[string]optimizedImage // By asset path
+}
+
+func newImageOptimizer(opts ImageOptions) *imageOptimizer {
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = DefaultImageQuality
+	}
+
+	widths := append([]int(nil), opts.Widths...)
+	sort.Ints(widths)
+	opts.Widths = widths
+
+	return &imageOptimizer{
+		opts:   opts,
+		images: make(map[string]optimizedImage),
+	}
+}
+
+// transform is used as Downloader transform. Assets other than JPEG and PNG images are kept as is.
+func (o *imageOptimizer) transform(asset Asset, body []byte) ([]byte, error) {
+	switch strings.ToLower(path.Ext(asset.Path)) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		return body, nil
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		// Not an image despite its extension, so it is written as is
+		return body, nil
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return body, nil
+	}
+	if format == "jpeg" && hasJPEGMetadata(body) {
+		// Re-encoding drops EXIF orientation and color profile, so the image would be displayed rotated or with wrong colors
+		return body, nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return body, nil
+	}
+
+	resized := fit(img, o.opts.MaxWidth, o.opts.MaxHeight)
+	optimized, err := o.encode(resized, format)
+	if err != nil {
+		return nil, fmt.Errorf("encode image: %w", err)
+	}
+	if resized == img && len(optimized) >= len(body) {
+		optimized = body
+	}
+
+	result := optimizedImage{
+		originalSize: int64(len(body)),
+		width:        resized.Bounds().Dx(),
+	}
+	for _, width := range o.opts.Widths {
+		if width <= 0 || width >= result.width {
+			continue
+		}
+
+		variant, err := o.encode(fit(resized, width, 0), format)
+		if err != nil {
+			return nil, fmt.Errorf("encode %dw variant: %w", width, err)
+		}
+
+		result.variants = append(result.variants, encodedVariant{width: width, body: variant})
+	}
+
+	o.mu.Lock()
+	o.images[asset.Path] = result
+	o.mu.Unlock()
+
+	return optimized, nil
+}
+
+func (o *imageOptimizer) encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: o.opts.Quality})
+	}
+
+	return buf.Bytes(), err
+}
+
+// writeVariants writes variants of optimized images and fills image information of download results
+func (o *imageOptimizer) writeVariants(ctx context.Context, storage Storage, results []DownloadResult, fingerprint bool) error {
+	for i, result := range results {
+		o.mu.Lock()
+		optimized, ok := o.images[result.Path]
+		o.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		results[i].Width = optimized.width
+		results[i].OriginalSize = optimized.originalSize
+		for _, variant := range optimized.variants {
+			hash := sha256.Sum256(variant.body)
+			imageVariant := ImageVariant{
+				Path:   ImageVariantPath(result.Path, variant.width),
+				Width:  variant.width,
+				Size:   int64(len(variant.body)),
+				SHA256: hex.EncodeToString(hash[:]),
+			}
+			if fingerprint {
+				imageVariant.Path = FingerprintPath(imageVariant.Path, imageVariant.SHA256)
+			}
+
+			results[i].Variants = append(results[i].Variants, imageVariant)
+
+			if existing, err := checksum(ctx, storage, imageVariant.Path); err == nil && existing == imageVariant.SHA256 {
+				continue
+			}
+			if err := storage.Put(ctx, imageVariant.Path, bytes.NewReader(variant.body)); err != nil {
+				return fmt.Errorf("put %s: %w", imageVariant.Path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasJPEGMetadata reports whether JPEG image has ICC color profile or EXIF orientation other than normal
+func hasJPEGMetadata(body []byte) bool {
+	if len(body) < 2 || body[0] != 0xFF || body[1] != 0xD8 {
+		return false
+	}
+
+	for i := 2; i+4 <= len(body); {
+		if body[i] != 0xFF {
+			return false
+		}
+
+		marker := body[i+1]
+		if marker == 0xFF {
+			i++ // Fill byte
+			continue
+		}
+		if marker == 0xD9 || marker == 0xDA {
+			return false // Metadata segments precede image data
+		}
+
+		length := int(binary.BigEndian.Uint16(body[i+2:]))
+		if length < 2 || i+2+length > len(body) {
+			return false
+		}
+
+		segment := body[i+4 : i+2+length]
+		switch {
+		case marker == 0xE2 && bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00")):
+			return true
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			if orientation := exifOrientation(segment[6:]); orientation > 1 {
+				return true
+			}
+		}
+
+		i += 2 + length
+	}
+
+	return false
+}
+
+// exifOrientation returns value of Orientation tag of the first IFD of EXIF TIFF data (0 if there is no tag)
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+	for i := range count {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+
+	return 0
+}
+
+// fit downscales the image to fit into maxWidth and maxHeight preserving aspect ratio (0 means no limit)
+func fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+	if scale == 1 {
+		return img
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, max(int(float64(width)*scale+0.5), 1), max(int(float64(height)*scale+0.5), 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// variantURL returns the key of image variant in URL mapping of the rewriter
+func variantURL(assetURL string, width int) string {
+	return assetURL + "#w" + strconv.Itoa(width)
+}
+
+// SetSrcset adds srcset attributes to img elements which src or lazy-load data-original is listed in srcsets
+// (srcset values keyed by URL). Lazy-loaded images also get loading="lazy", as browsers load srcset regardless
+// of lazy-load scripts. Elements that already have srcset and background images (e.g. data-content-cover-bg
+// of covers) are kept as is.
+func SetSrcset(htmlCode string, srcsets map[string]string) (string, error) {
+	normalized := make(map[string]string, len(srcsets))
+	for from, srcset := range srcsets {
+		normalized[normalizeURL(from)] = srcset
+	}
+
+	var out bytes.Buffer
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlCode))
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return out.String(), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(tokenizer.Raw())
+			token := tokenizer.Token()
+
+			if token.Data != "img" || attrValue(token.Attr, "srcset") != "" {
+				out.WriteString(raw)
+				continue
+			}
+
+			srcset, ok := normalized[normalizeURL(attrValue(token.Attr, "src"))]
+			lazySrcset, lazy := normalized[normalizeURL(attrValue(token.Attr, "data-original"))]
+			if lazy {
+				srcset, ok = lazySrcset, true
+			}
+			if !ok {
+				out.WriteString(raw)
+				continue
+			}
+
+			token.Attr = append(token.Attr, html.Attribute{Key: "srcset", Val: srcset})
+			if lazy && attrValue(token.Attr, "loading") == "" {
+				token.Attr = append(token.Attr, html.Attribute{Key: "loading", Val: "lazy"})
+			}
+			out.WriteString(token.String())
+		default:
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
+
+// imageSrcsets returns srcset values of downloaded images with variants and URL mapping of their variants
+func imageSrcsets(assets []Asset, results map[string]DownloadResult) (map[string]string, map[string]string) {
+	srcsets := make(map[string]string)
+	mapping := make(map[string]string)
+	for _, asset := range assets {
+		result, ok := results[asset.Path]
+		if !ok || len(result.Variants) == 0 {
+			continue
+		}
+
+		candidates := make([]string, 0, len(result.Variants)+1)
+		for _, variant := range result.Variants {
+			key := variantURL(asset.URL, variant.Width)
+			mapping[key] = variant.Path
+			candidates = append(candidates, key+" "+strconv.Itoa(variant.Width)+"w")
+		}
+		candidates = append(candidates, asset.URL+" "+strconv.Itoa(result.Width)+"w")
+
+		srcsets[asset.URL] = strings.Join(candidates, ", ")
+	}
+
+	return srcsets, mapping
+}
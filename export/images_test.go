@@ -0,0 +1,192 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: uint8((x + y) % 256), A: 255})
+		}
+	}
+
+	return img
+}
+
+func TestImageVariantPath(t *testing.T) {
+	assert.Equal(t, "images/photo.w480.jpg", ImageVariantPath("images/photo.jpg", 480))
+	assert.Equal(t, "images/photo.w480", ImageVariantPath("images/photo", 480))
+}
+
+func TestSetSrcset(t *testing.T) {
+	got, err := SetSrcset(`<img src="https://static.tildacdn.com/img/photo1.jpg" alt="Photo"><img src="//static.tildacdn.com/img/photo1.jpg" srcset="photo.jpg 2x"><div data-original="https://static.tildacdn.com/img/photo1.jpg"></div>`,
+		map[string]string{"https://static.tildacdn.com/img/photo1.jpg": "a.jpg 100w, b.jpg 200w"})
+	assert.NoError(t, err)
+	assert.Equal(t, `<img src="https://static.tildacdn.com/img/photo1.jpg" alt="Photo" srcset="a.jpg 100w, b.jpg 200w"><img src="//static.tildacdn.com/img/photo1.jpg" srcset="photo.jpg 2x"><div data-original="https://static.tildacdn.com/img/photo1.jpg"></div>`, got)
+}
+
+func TestSetSrcset_LazyLoad(t *testing.T) {
+	got, err := SetSrcset(`<img src="https://thb.tildacdn.com/tild/-/resizeb/20x/photo1.jpg" data-original="https://static.tildacdn.com/img/photo1.jpg" class="t-img"><img data-original="https://static.tildacdn.com/img/photo1.jpg" loading="eager"><div class="t-cover__carrier" data-content-cover-bg="https://static.tildacdn.com/img/photo1.jpg"></div>`,
+		map[string]string{"https://static.tildacdn.com/img/photo1.jpg": "a.jpg 100w, b.jpg 200w"})
+	assert.NoError(t, err)
+	assert.Equal(t, `<img src="https://thb.tildacdn.com/tild/-/resizeb/20x/photo1.jpg" data-original="https://static.tildacdn.com/img/photo1.jpg" class="t-img" srcset="a.jpg 100w, b.jpg 200w" loading="lazy"><img data-original="https://static.tildacdn.com/img/photo1.jpg" loading="eager" srcset="a.jpg 100w, b.jpg 200w"><div class="t-cover__carrier" data-content-cover-bg="https://static.tildacdn.com/img/photo1.jpg"></div>`, got)
+}
+
+// withJPEGSegment inserts the segment with the marker right after SOI marker of JPEG image
+func withJPEGSegment(t *testing.T, marker byte, payload []byte) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, testImage(8, 8), nil))
+	jpg := buf.Bytes()
+
+	segment := append([]byte{0xFF, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+// exifWithOrientation returns EXIF payload of APP1 segment with big-endian TIFF data containing Orientation tag
+func exifWithOrientation(orientation byte) []byte {
+	return append([]byte("Exif\x00\x00MM\x00\x2A\x00\x00\x00\x08\x00\x01"),
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, orientation, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+}
+
+func TestHasJPEGMetadata(t *testing.T) {
+	var plain bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&plain, testImage(8, 8), nil))
+
+	tests := []struct {
+		name string
+		body []byte
+		want bool
+	}{
+		{name: "plain", body: plain.Bytes()},
+		{name: "normal orientation", body: withJPEGSegment(t, 0xE1, exifWithOrientation(1))},
+		{name: "rotated", body: withJPEGSegment(t, 0xE1, exifWithOrientation(6)), want: true},
+		{name: "icc profile", body: withJPEGSegment(t, 0xE2, []byte("ICC_PROFILE\x00\x01\x01profile")), want: true},
+		{name: "truncated", body: withJPEGSegment(t, 0xE1, exifWithOrientation(6))[:12]},
+		{name: "not jpeg", body: []byte("GIF89a")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hasJPEGMetadata(tt.body))
+		})
+	}
+}
+
+func TestImageOptimizer_Transform_Skipped(t *testing.T) {
+	var large bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&large, testImage(8, 8), nil))
+	bomb := large.Bytes()
+	// Image declares 10000x10000 pixels in its frame header, so it is not decoded
+	sof := bytes.Index(bomb, []byte{0xFF, 0xC0})
+	copy(bomb[sof+5:], []byte{0x27, 0x10, 0x27, 0x10})
+	config, err := jpeg.DecodeConfig(bytes.NewReader(bomb))
+	assert.NoError(t, err)
+	assert.Equal(t, 10000, config.Width)
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{name: "too many pixels", body: bomb},
+		{name: "rotated", body: withJPEGSegment(t, 0xE1, exifWithOrientation(6))},
+		{name: "not an image", body: []byte("photo")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			optimizer := newImageOptimizer(ImageOptions{MaxWidth: 4, Widths: []int{2}})
+			got, err := optimizer.transform(Asset{Path: "images/photo.jpg"}, tt.body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, got)
+			assert.Empty(t, optimizer.images)
+		})
+	}
+}
+
+func TestProject_WithImageOptimization(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var photo bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&photo, testImage(400, 200), &jpeg.Options{Quality: 100}))
+	var icon bytes.Buffer
+	assert.NoError(t, png.Encode(&icon, testImage(16, 16)))
+
+	page := testPage("2", 20, "blog")
+	page.Images = append(page.Images, testPage("1", 10, "").Images...)
+	page.Images[1].To = "icon.png"
+	registerTestProject(page)
+	registerAsset("https://static.tildacdn.com/img/photo2.jpg", photo.String())
+	registerAsset("https://static.tildacdn.com/img/photo1.jpg", icon.String())
+
+	root := t.TempDir()
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithImageOptimization(ImageOptions{
+		MaxWidth: 300,
+		Quality:  70,
+		Widths:   []int{1000, 100},
+	}))
+	assert.NoError(t, err)
+
+	asset, ok := result.Manifest.Asset("images/photo2.jpg")
+	if assert.True(t, ok) {
+		assert.Equal(t, 300, asset.Width)
+		assert.Equal(t, int64(photo.Len()), asset.OriginalSize)
+		assert.Less(t, asset.Size, asset.OriginalSize)
+		if assert.Len(t, asset.Variants, 1) {
+			assert.Equal(t, "images/photo2.w100.jpg", asset.Variants[0].Path)
+			assert.Equal(t, 100, asset.Variants[0].Width)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(root, "images", "photo2.jpg"))
+	assert.NoError(t, err)
+	config, err := jpeg.DecodeConfig(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 300, config.Width)
+	assert.Equal(t, 150, config.Height)
+
+	f, err = os.Open(filepath.Join(root, "images", "photo2.w100.jpg"))
+	assert.NoError(t, err)
+	config, err = jpeg.DecodeConfig(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 100, config.Width)
+	assert.Equal(t, 50, config.Height)
+
+	// Small PNG is kept as is because re-encoding doesn't make it smaller
+	asset, ok = result.Manifest.Asset("images/icon.png")
+	if assert.True(t, ok) {
+		assert.Equal(t, 16, asset.Width)
+		assert.Empty(t, asset.Variants)
+	}
+
+	bts, err := os.ReadFile(filepath.Join(root, "blog", "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), `<img src="../images/photo2.jpg" srcset="../images/photo2.w100.jpg 100w, ../images/photo2.jpg 300w">`)
+
+	// Variants of images no longer used are removed
+	page.Images = page.Images[1:]
+	page.Published++
+	registerTestProject(page)
+	result, err = Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithImageOptimization(ImageOptions{
+		MaxWidth: 300,
+		Quality:  70,
+		Widths:   []int{1000, 100},
+	}))
+	assert.NoError(t, err)
+	assert.Contains(t, result.Removed, "images/photo2.w100.jpg")
+	_, err = os.Stat(filepath.Join(root, "images", "photo2.w100.jpg"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// IntegrityMap returns Subresource Integrity hashes of scripts and styles as Tilda CDN serves them keyed by source URL.
+// It can be used as tilda.TagOptions.Integrity to render tags referencing Tilda CDN. Local copies that were
+// transformed (e.g. styles rewritten for fingerprinted assets) differ from CDN files, their hashes are Integrity
+// of download results.
+func IntegrityMap(results []DownloadResult) map[string]string {
+	integrity := make(map[string]string, len(results))
+	for _, result := range results {
+		switch {
+		case result.SourceIntegrity != "":
+			integrity[result.URL] = result.SourceIntegrity
+		case result.Integrity != "":
+			integrity[result.URL] = result.Integrity
+		}
+	}
+
+	return integrity
+}
+
+// SetIntegrity adds integrity attributes to script and stylesheet link tags referencing URLs listed in integrity
+// (hashes keyed by source URL, URLs are matched the same way Rewriter does). Tags that get integrity also get crossorigin attribute if they don't have it,
+// so that the check works for assets kept on Tilda CDN. Other markup is kept as is.
+func SetIntegrity(htmlCode string, integrity map[string]string, crossOrigin string) (string, error) {
+	normalized := normalizeURLs(integrity)
+
+	var out bytes.Buffer
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlCode))
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return out.String(), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(tokenizer.Raw())
+			token := tokenizer.Token()
+
+			var src string
+			switch {
+			case token.Data == "script":
+				src = attrValue(token.Attr, "src")
+			case token.Data == "link" && isStylesheetLink(token.Attr):
+				src = attrValue(token.Attr, "href")
+			}
+
+			hash, ok := lookupURL(normalized, src)
+			if src == "" || !ok {
+				out.WriteString(raw)
+				continue
+			}
+
+			token.Attr = setAttr(token.Attr, "integrity", hash, true)
+			if crossOrigin != "" {
+				token.Attr = setAttr(token.Attr, "crossorigin", crossOrigin, false)
+			}
+			out.WriteString(token.String())
+		default:
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
+
+// isSubresource reports whether the asset is a script or a style that can be checked with integrity attribute
+func isSubresource(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".js", ".mjs", ".css":
+		return true
+	}
+
+	return false
+}
+
+func isStylesheetLink(attrs []html.Attribute) bool {
+	for _, rel := range strings.Fields(attrValue(attrs, "rel")) {
+		if strings.EqualFold(rel, "stylesheet") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setAttr sets value of the attribute, existing attribute is kept unless replace is set
+func setAttr(attrs []html.Attribute, key, value string, replace bool) []html.Attribute {
+	for i, attr := range attrs {
+		if attr.Key == key {
+			if replace {
+				attrs[i].Val = value
+			}
+
+			return attrs
+		}
+	}
+
+	return append(attrs, html.Attribute{Key: key, Val: value})
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestSetIntegrity(t *testing.T) {
+	integrity := map[string]string{
+		"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js": "sha384-js",
+		"https://static.tildacdn.com/css/tilda-grid-3.0.min.css":  "sha384-css",
+
+		"https://static.tildacdn.com/ws/project54321/tilda-blocks-page1.min.css?t=123": "sha384-blocks",
+	}
+
+	tests := []struct {
+		name     string
+		htmlCode string
+		want     string
+	}{
+		{
+			name:     "script",
+			htmlCode: `<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js" defer></script>`,
+			want:     `<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js" defer="" integrity="sha384-js" crossorigin="anonymous"></script>`,
+		}, {
+			name:     "protocol-relative stylesheet",
+			htmlCode: `<link rel="stylesheet" href="//static.tildacdn.com/css/tilda-grid-3.0.min.css" crossorigin="use-credentials">`,
+			want:     `<link rel="stylesheet" href="//static.tildacdn.com/css/tilda-grid-3.0.min.css" crossorigin="use-credentials" integrity="sha384-css">`,
+		}, {
+			name:     "existing integrity",
+			htmlCode: `<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js" integrity="sha384-old"></script>`,
+			want:     `<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js" integrity="sha384-js" crossorigin="anonymous"></script>`,
+		}, {
+			name:     "query string",
+			htmlCode: `<link rel="stylesheet" href="https://static.tildacdn.com/ws/project54321/tilda-blocks-page1.min.css?t=123"><script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js?t=456"></script>`,
+			want:     `<link rel="stylesheet" href="https://static.tildacdn.com/ws/project54321/tilda-blocks-page1.min.css?t=123" integrity="sha384-blocks" crossorigin="anonymous"><script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js?t=456" integrity="sha384-js" crossorigin="anonymous"></script>`,
+		}, {
+			name:     "other tags",
+			htmlCode: `<link rel="preload" href="https://static.tildacdn.com/css/tilda-grid-3.0.min.css"><script src="https://static.tildacdn.com/js/other.js"></script><script>var a = 1;</script>`,
+			want:     `<link rel="preload" href="https://static.tildacdn.com/css/tilda-grid-3.0.min.css"><script src="https://static.tildacdn.com/js/other.js"></script><script>var a = 1;</script>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetIntegrity(tt.htmlCode, integrity, tilda.CrossOriginAnonymous)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIntegrityMap(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js": "sha384-js",
+	}, IntegrityMap([]DownloadResult{
+		{Asset: Asset{URL: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"}, Integrity: "sha384-js"},
+		{Asset: Asset{URL: "https://static.tildacdn.com/img/photo1.jpg"}},
+	}))
+
+	// Tags referencing Tilda CDN need hashes of files as CDN serves them, not of transformed local copies
+	assert.Equal(t, map[string]string{
+		"https://static.tildacdn.com/css/tilda-grid-3.0.min.css": "sha384-source",
+	}, IntegrityMap([]DownloadResult{
+		{Asset: Asset{URL: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css"}, Integrity: "sha384-local", SourceIntegrity: "sha384-source"},
+	}))
+}
+
+func TestProject_WithIntegrity(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	page := testPage("2", 20, "blog")
+	page.HTML = `<html><head><link rel="stylesheet" href="https://static.tildacdn.com/css/fonts-tildasans.css"><script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"></script></head><body></body></html>`
+	registerTestProject(page)
+
+	root := t.TempDir()
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithIntegrity(), WithAssetFingerprints())
+	assert.NoError(t, err)
+
+	css := FingerprintPath("css/fonts-tildasans.css", sha256Hex("fonts"))
+	js := FingerprintPath("js/tilda-scripts-3.0.min.js", sha256Hex("scripts"))
+	bts, err := os.ReadFile(filepath.Join(root, "blog", "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<html><head><link rel="stylesheet" href="../`+css+`" integrity="`+tilda.IntegrityHash([]byte("fonts"))+`" crossorigin="anonymous">`+
+		`<script src="../`+js+`" integrity="`+tilda.IntegrityHash([]byte("scripts"))+`" crossorigin="anonymous"></script></head><body></body></html>`, string(bts))
+
+	asset, ok := result.Manifest.Asset(js)
+	if assert.True(t, ok) {
+		assert.Equal(t, tilda.IntegrityHash([]byte("scripts")), asset.Integrity)
+	}
+}
+
+func TestProject_WithIntegrityTransformed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	page := testPage("2", 20, "blog")
+	page.HTML = `<html><head><link rel="stylesheet" href="https://static.tildacdn.com/css/tilda-blocks-page2.min.css?t=1734259633"></head><body></body></html>`
+	page.CSS = []tilda.CSS{{From: "https://static.tildacdn.com/css/tilda-blocks-page2.min.css", To: "tilda-blocks-page2.min.css"}}
+	registerTestProject(page)
+	const style = `.t-cover{background-image:url('https://static.tildacdn.com/img/photo2.jpg')}`
+	registerAsset("https://static.tildacdn.com/css/tilda-blocks-page2.min.css", style)
+
+	root := t.TempDir()
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithIntegrity(), WithAssetFingerprints())
+	assert.NoError(t, err)
+
+	var css DownloadResult
+	for _, asset := range result.Assets {
+		if asset.Path == "css/tilda-blocks-page2.min.css" {
+			css = asset
+		}
+	}
+	local, err := os.ReadFile(filepath.Join(root, css.StoragePath()))
+	assert.NoError(t, err)
+	assert.NotEqual(t, style, string(local))
+	assert.Equal(t, tilda.IntegrityHash(local), css.Integrity)
+	assert.Equal(t, tilda.IntegrityHash([]byte(style)), css.SourceIntegrity)
+	assert.Equal(t, tilda.IntegrityHash([]byte(style)), IntegrityMap(result.Assets)["https://static.tildacdn.com/css/tilda-blocks-page2.min.css"])
+
+	// The tag referencing the asset with query string gets the hash of the local copy it is rewritten to
+	bts, err := os.ReadFile(filepath.Join(root, "blog", "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), `<link rel="stylesheet" href="../`+css.StoragePath()+`" integrity="`+css.Integrity+`" crossorigin="anonymous">`)
+}
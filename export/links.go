@@ -0,0 +1,643 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// ErrBrokenLinks is returned by LinkReport.Err when broken links were found
+var ErrBrokenLinks = errors.New("broken links found")
+
+// LinkIssueKind is the kind of problem with the link
+type LinkIssueKind string
+
+const (
+	LinkUnpublished    LinkIssueKind = "unpublished_page" // Link leads to the page that is not published
+	LinkDeletedPage    LinkIssueKind = "deleted_page"     // Link leads to the page that existed in previous exports and was deleted
+	LinkOldAlias       LinkIssueKind = "old_alias"        // Link uses previous alias or file name of the page
+	LinkUnknownPage    LinkIssueKind = "unknown_page"     // Link leads to the path that is not served by any page of the project
+	LinkMissingAnchor  LinkIssueKind = "missing_anchor"   // Linked page has no element with the anchor name or id
+	LinkInvalidMailto  LinkIssueKind = "invalid_mailto"   // mailto link has invalid addresses
+	LinkInvalidTel     LinkIssueKind = "invalid_tel"      // tel link has invalid phone number
+	LinkExternalBroken LinkIssueKind = "external_broken"  // External link request failed or returned error status
+)
+
+// tildaHashLinks are hash links handled by Tilda scripts instead of scrolling to anchors
+var tildaHashLinks = map[string]bool{
+	"":         true,
+	"top":      true,
+	"order":    true,
+	"opencart": true,
+}
+
+// tildaHashRoutePrefix starts hash links routed by Tilda scripts (e.g. #!/tproduct/123-456 opens product popup)
+const tildaHashRoutePrefix = "!/"
+
+var (
+	pageFileRegexp = regexp.MustCompile(`^page([0-9]+)\.html$`)
+	telRegexp      = regexp.MustCompile(`^\+?[0-9][0-9 ()\-.]*$`)
+)
+
+// LinkPage represents page of the project which links are checked
+type LinkPage struct {
+	ID        string
+	Alias     string
+	Path      string // Path of the page file which relative links are resolved against (e.g. page2.html)
+	Published bool   // Page is published, so links to it work
+	HTML      string // HTML code of the page (empty if the page was not exported or fetched)
+}
+
+// LinkReport represents result of link checking
+type LinkReport struct {
+	Graph  map[string][]string // Sorted IDs of project pages linked from each page by page ID
+	Issues []LinkIssue         // Issues in the order of pages and links
+}
+
+// LinkIssue represents broken link
+type LinkIssue struct {
+	Kind     LinkIssueKind
+	PageID   string // ID of the page containing the link
+	Href     string // Link as written in the page
+	TargetID string // ID of the linked page (empty if the link doesn't lead to the project page)
+	Status   int    // HTTP status code of external link (0 if the request failed)
+	Message  string // Details of the problem (e.g. error of external link request)
+}
+
+// String converts issue to human-readable form
+func (i LinkIssue) String() string {
+	s := fmt.Sprintf("page %s: %s %s", i.PageID, i.Kind, i.Href)
+	if i.Message != "" {
+		s += ": " + i.Message
+	}
+
+	return s
+}
+
+// OK reports whether no issues were found
+func (r *LinkReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns error wrapping ErrBrokenLinks if issues were found
+func (r *LinkReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+
+	messages := make([]string, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		messages = append(messages, issue.String())
+	}
+
+	return fmt.Errorf("%w: %d issues: %s", ErrBrokenLinks, len(r.Issues), strings.Join(messages, "; "))
+}
+
+// LinkChecker checks links between pages of the project and, optionally, external links
+type LinkChecker struct {
+	project     tilda.ProjectInfo
+	previous    []*Manifest
+	httpClient  *http.Client
+	concurrency int
+}
+
+// NewLinkChecker creates new checker of links of the project pages
+func NewLinkChecker(project tilda.ProjectInfo, options ...func(*LinkChecker)) *LinkChecker {
+	checker := &LinkChecker{
+		project:     project,
+		concurrency: 4,
+	}
+
+	for _, o := range options {
+		o(checker)
+	}
+
+	return checker
+}
+
+// WithPreviousManifests option allows to distinguish links to deleted pages and previous aliases of pages
+// from links to unknown paths
+func WithPreviousManifests(manifests ...*Manifest) func(*LinkChecker) {
+	return func(c *LinkChecker) {
+		c.previous = append(c.previous, manifests...)
+	}
+}
+
+// WithExternalLinks option enables checking of external links with httpClient (http.DefaultClient if nil)
+func WithExternalLinks(httpClient *http.Client) func(*LinkChecker) {
+	return func(c *LinkChecker) {
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		c.httpClient = httpClient
+	}
+}
+
+// WithLinkConcurrency option allows to set max number of simultaneous requests of external links
+func WithLinkConcurrency(concurrency int) func(*LinkChecker) {
+	return func(c *LinkChecker) {
+		c.concurrency = max(concurrency, 1)
+	}
+}
+
+// CheckLinks checks links of pages exported into the local directory (see ExportedLinkPages)
+func CheckLinks(ctx context.Context, dir string, options ...func(*LinkChecker)) (*LinkReport, error) {
+	fsys := os.DirFS(dir)
+	f, err := fsys.Open(ManifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	manifest, err := ReadManifest(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := ExportedLinkPages(fsys, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLinkChecker(manifest.Project.ProjectInfo(), options...).Check(ctx, pages)
+}
+
+// ExportedLinkPages returns pages listed in the manifest with HTML code read from their files
+func ExportedLinkPages(fsys fs.FS, manifest *Manifest) ([]LinkPage, error) {
+	pages := make([]LinkPage, 0, len(manifest.Pages))
+	for _, page := range manifest.Pages {
+		bts, err := fs.ReadFile(fsys, page.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read page %s: %w", page.ID, err)
+		}
+
+		pages = append(pages, LinkPage{
+			ID:        page.ID,
+			Alias:     page.Alias,
+			Path:      page.Path,
+			Published: true,
+			HTML:      string(bts),
+		})
+	}
+
+	return pages, nil
+}
+
+// FetchedLinkPages returns pages of the project listing with HTML code of fetched pages.
+// Pages of the listing that were never published are unpublished.
+func FetchedLinkPages(listing []tilda.Page, pages []tilda.PageExport) []LinkPage {
+	fetched := make(map[string]tilda.PageExport, len(pages))
+	for _, page := range pages {
+		fetched[page.ID] = page
+	}
+
+	linkPages := make([]LinkPage, 0, len(listing))
+	for _, page := range listing {
+		linkPage := LinkPage{
+			ID:        page.ID,
+			Alias:     page.Alias,
+			Path:      firstNonEmpty(page.Filename, "page"+page.ID+".html"),
+			Published: page.Published != 0,
+		}
+		if export, ok := fetched[page.ID]; ok {
+			linkPage.HTML = export.HTML
+		}
+
+		linkPages = append(linkPages, linkPage)
+	}
+
+	return linkPages
+}
+
+// pageLinks represents links and anchors found in HTML code of the page
+type pageLinks struct {
+	hrefs   []string
+	anchors map[string]bool
+}
+
+// Check builds graph of links between pages and checks every link of every page
+func (c *LinkChecker) Check(ctx context.Context, pages []LinkPage) (*LinkReport, error) {
+	parsed := make([]pageLinks, len(pages))
+	for i, page := range pages {
+		links, err := parseLinks(page.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("parse page %s: %w", page.ID, err)
+		}
+
+		parsed[i] = links
+	}
+
+	index := newLinkIndex(c.project, pages, parsed, c.previous)
+
+	var external []string
+	if c.httpClient != nil {
+		for i, page := range pages {
+			for _, href := range parsed[i].hrefs {
+				if index.classify(page, href).external {
+					external = append(external, href)
+				}
+			}
+		}
+	}
+	externalIssues, err := c.checkExternal(ctx, external)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LinkReport{Graph: make(map[string][]string, len(pages))}
+	for i, page := range pages {
+		linked := make(map[string]bool)
+		for _, href := range parsed[i].hrefs {
+			link := index.classify(page, href)
+			if _, ok := index.pages[link.targetID]; ok {
+				linked[link.targetID] = true
+			}
+
+			issue := link.issue
+			if link.external {
+				issue = externalIssues[href]
+			}
+			if issue.Kind == "" {
+				continue
+			}
+
+			issue.PageID = page.ID
+			issue.Href = href
+			report.Issues = append(report.Issues, issue)
+		}
+
+		report.Graph[page.ID] = sortedKeys(linked)
+	}
+
+	return report, nil
+}
+
+// checkExternal requests unique URLs concurrently and returns issues of broken URLs
+func (c *LinkChecker) checkExternal(ctx context.Context, urls []string) (map[string]LinkIssue, error) {
+	issues := make(map[string]LinkIssue)
+	seen := make(map[string]bool, len(urls))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(c.concurrency, 1))
+	for _, u := range urls {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if issue, broken := c.checkURL(ctx, u); broken {
+				mu.Lock()
+				issues[u] = issue
+				mu.Unlock()
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	return issues, ctx.Err()
+}
+
+// checkURL requests URL with HEAD method falling back to GET for servers that don't support HEAD
+func (c *LinkChecker) checkURL(ctx context.Context, u string) (LinkIssue, bool) {
+	var status int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return LinkIssue{Kind: LinkExternalBroken, Message: err.Error()}, true
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return LinkIssue{Kind: LinkExternalBroken, Message: err.Error()}, true
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	if status >= http.StatusBadRequest {
+		return LinkIssue{Kind: LinkExternalBroken, Status: status, Message: http.StatusText(status)}, true
+	}
+
+	return LinkIssue{}, false
+}
+
+// parseLinks returns href values of a and area elements and anchors (id attributes and names of a elements)
+func parseLinks(htmlCode string) (pageLinks, error) {
+	links := pageLinks{anchors: make(map[string]bool)}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlCode))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return pageLinks{}, fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return links, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if id := attrValue(token.Attr, "id"); id != "" {
+				links.anchors[id] = true
+			}
+
+			if token.Data != "a" && token.Data != "area" {
+				continue
+			}
+			if name := attrValue(token.Attr, "name"); name != "" {
+				links.anchors[name] = true
+			}
+			if href := strings.TrimSpace(attrValue(token.Attr, "href")); href != "" {
+				links.hrefs = append(links.hrefs, href)
+			}
+		}
+	}
+}
+
+// linkIndex resolves links to pages of the project
+type linkIndex struct {
+	project  tilda.ProjectInfo
+	hosts    map[string]bool            // Hosts of the project site
+	pages    map[string]LinkPage        // Pages by ID
+	anchors  map[string]map[string]bool // Anchors of pages with HTML code by page ID
+	paths    map[string]string          // Page IDs by file path and alias
+	previous map[string]string          // Page IDs by file paths and aliases of previous exports
+}
+
+func newLinkIndex(project tilda.ProjectInfo, pages []LinkPage, parsed []pageLinks, previous []*Manifest) *linkIndex {
+	index := &linkIndex{
+		project:  project,
+		hosts:    make(map[string]bool),
+		pages:    make(map[string]LinkPage, len(pages)),
+		anchors:  make(map[string]map[string]bool, len(pages)),
+		paths:    make(map[string]string),
+		previous: make(map[string]string),
+	}
+
+	for _, domain := range []string{project.CustomDomain, project.URL} {
+		if domain = strings.TrimSpace(domain); domain == "" {
+			continue
+		}
+		if !strings.Contains(domain, "://") {
+			domain = "https://" + domain
+		}
+		if u, err := url.Parse(domain); err == nil && u.Host != "" {
+			index.hosts[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")] = true
+		}
+	}
+
+	for i, page := range pages {
+		index.pages[page.ID] = page
+		if page.HTML != "" {
+			index.anchors[page.ID] = parsed[i].anchors
+		}
+		for _, name := range []string{page.Path, strings.Trim(page.Alias, "/")} {
+			if name != "" {
+				index.paths[name] = page.ID
+			}
+		}
+	}
+
+	for _, manifest := range previous {
+		if manifest == nil {
+			continue
+		}
+
+		for _, page := range manifest.Pages {
+			for _, name := range append([]string{page.Path, strings.Trim(page.Alias, "/")}, page.Copies...) {
+				if _, ok := index.paths[name]; name != "" && !ok {
+					index.previous[name] = page.ID
+				}
+			}
+		}
+	}
+
+	return index
+}
+
+// classifiedLink represents resolved link
+type classifiedLink struct {
+	targetID string    // ID of the linked page of the project
+	external bool      // Link leads to other site
+	issue    LinkIssue // Problem with the link (empty Kind if the link is fine)
+}
+
+// classify resolves the link of the page and checks it without requests
+func (x *linkIndex) classify(page LinkPage, href string) classifiedLink {
+	scheme, rest, hasScheme := strings.Cut(href, ":")
+	if hasScheme && !strings.ContainsAny(scheme, "/?#") {
+		switch strings.ToLower(scheme) {
+		case "mailto":
+			if err := validMailto(rest); err != nil {
+				return classifiedLink{issue: LinkIssue{Kind: LinkInvalidMailto, Message: err.Error()}}
+			}
+			return classifiedLink{}
+		case "tel":
+			if err := validTel(rest); err != nil {
+				return classifiedLink{issue: LinkIssue{Kind: LinkInvalidTel, Message: err.Error()}}
+			}
+			return classifiedLink{}
+		case "http", "https":
+		default:
+			return classifiedLink{}
+		}
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return classifiedLink{}
+	}
+
+	if u.Host != "" && !x.hosts[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")] {
+		return classifiedLink{external: true}
+	}
+
+	targetID := page.ID
+	if u.Host != "" || u.Path != "" {
+		name := u.Path
+		if !strings.HasPrefix(name, "/") && u.Host == "" {
+			name = path.Join(path.Dir(page.Path), name)
+		}
+		name = strings.Trim(path.Clean("/"+name), "/")
+
+		id, kind := x.resolve(name)
+		switch kind {
+		case "":
+		case linkNotPage:
+			return classifiedLink{}
+		default:
+			return classifiedLink{targetID: id, issue: LinkIssue{Kind: kind, TargetID: id}}
+		}
+		targetID = id
+	}
+
+	link := classifiedLink{targetID: targetID}
+	if target := x.pages[targetID]; !target.Published {
+		link.issue = LinkIssue{Kind: LinkUnpublished, TargetID: targetID}
+		return link
+	}
+
+	anchor, err := url.PathUnescape(u.Fragment)
+	if err != nil {
+		anchor = u.Fragment
+	}
+	if anchors, ok := x.anchors[targetID]; ok && !isTildaHashLink(anchor) && !strings.Contains(anchor, ":") && !anchors[anchor] {
+		link.issue = LinkIssue{Kind: LinkMissingAnchor, TargetID: targetID}
+	}
+	if targetID == page.ID && u.Path == "" && u.Host == "" {
+		// Links to anchors of the same page are not edges of the page graph
+		link.targetID = ""
+	}
+
+	return link
+}
+
+// linkNotPage is the kind of links to files that are not pages (e.g. images or documents)
+const linkNotPage LinkIssueKind = "not_page"
+
+// resolve returns ID of the page served by the path relative to the site root or kind of the problem
+func (x *linkIndex) resolve(name string) (string, LinkIssueKind) {
+	switch name {
+	case "", IndexFilename:
+		if isPageID(x.project.IndexpageID) {
+			return x.known(x.project.IndexpageID)
+		}
+	case NotFoundFilename:
+		if isPageID(x.project.Page404ID) {
+			return x.known(x.project.Page404ID)
+		}
+	}
+
+	for _, candidate := range []string{name, strings.TrimSuffix(name, "/"+IndexFilename)} {
+		if id, ok := x.paths[candidate]; ok {
+			return id, ""
+		}
+	}
+
+	if m := pageFileRegexp.FindStringSubmatch(name); m != nil {
+		return x.known(m[1])
+	}
+
+	for _, candidate := range []string{name, strings.TrimSuffix(name, "/"+IndexFilename)} {
+		if id, ok := x.previous[candidate]; ok {
+			if _, exists := x.pages[id]; exists {
+				return id, LinkOldAlias
+			}
+
+			return id, LinkDeletedPage
+		}
+	}
+
+	if ext := path.Ext(name); ext != "" && !strings.EqualFold(ext, ".html") && !strings.EqualFold(ext, ".htm") {
+		return "", linkNotPage
+	}
+
+	return "", LinkUnknownPage
+}
+
+// known returns the page ID if the page exists now, previously exported page is deleted and other page is unknown
+func (x *linkIndex) known(id string) (string, LinkIssueKind) {
+	if _, ok := x.pages[id]; ok {
+		return id, ""
+	}
+
+	for _, prevID := range x.previous {
+		if prevID == id {
+			return id, LinkDeletedPage
+		}
+	}
+
+	return id, LinkUnknownPage
+}
+
+// isTildaHashLink reports whether the hash link is handled by Tilda scripts instead of scrolling to the anchor
+func isTildaHashLink(anchor string) bool {
+	return tildaHashLinks[anchor] || strings.HasPrefix(anchor, tildaHashRoutePrefix)
+}
+
+// validMailto checks addresses of mailto link (RFC 6068)
+func validMailto(value string) error {
+	addrs, headers, _ := strings.Cut(value, "?")
+	addrs, err := url.PathUnescape(addrs)
+	if err != nil {
+		return fmt.Errorf("unescape: %w", err)
+	}
+
+	if strings.TrimSpace(addrs) == "" {
+		// Addresses are optional if there are headers (RFC 6068), e.g. mailto:?subject=... lets user choose recipients
+		if headers != "" {
+			return nil
+		}
+
+		return errors.New("no address")
+	}
+
+	for _, addr := range strings.Split(addrs, ",") {
+		parsed, err := mail.ParseAddress(strings.TrimSpace(addr))
+		if err != nil {
+			return fmt.Errorf("address %q: %w", strings.TrimSpace(addr), err)
+		}
+
+		if _, domain, _ := strings.Cut(parsed.Address, "@"); !strings.Contains(domain, ".") {
+			return fmt.Errorf("address %q: domain has no dot", parsed.Address)
+		}
+	}
+
+	return nil
+}
+
+// validTel checks phone number of tel link: optional plus sign and 3-15 digits with visual separators
+func validTel(value string) error {
+	number, _, _ := strings.Cut(value, ";")
+	number, err := url.PathUnescape(number)
+	if err != nil {
+		return fmt.Errorf("unescape: %w", err)
+	}
+
+	number = strings.TrimSpace(number)
+	if !telRegexp.MatchString(number) {
+		return fmt.Errorf("phone number %q contains invalid characters", number)
+	}
+
+	digits := 0
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 3 || digits > 15 {
+		return fmt.Errorf("phone number %q has %d digits", number, digits)
+	}
+
+	return nil
+}
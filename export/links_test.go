@@ -0,0 +1,182 @@
+package export
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestLinkChecker_Check(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodHead, "https://other.example.org/ok", httpmock.NewStringResponder(http.StatusOK, ""))
+	httpmock.RegisterResponder(http.MethodHead, "https://other.example.org/head", httpmock.NewStringResponder(http.StatusMethodNotAllowed, ""))
+	httpmock.RegisterResponder(http.MethodGet, "https://other.example.org/head", httpmock.NewStringResponder(http.StatusOK, ""))
+	httpmock.RegisterResponder(http.MethodHead, "https://other.example.org/missing", httpmock.NewStringResponder(http.StatusNotFound, ""))
+
+	project := tilda.ProjectInfo{ID: "54321", CustomDomain: "example.com", IndexpageID: "1", Page404ID: "3"}
+	listing := []tilda.Page{
+		{ID: "1", Published: 1734259410},
+		{ID: "2", Alias: "blog", Published: 1734259420},
+		{ID: "3", Alias: "not-found", Published: 1734259430},
+		{ID: "4", Alias: "draft"},
+	}
+	links := []string{
+		"/blog",
+		"https://www.example.com/blog#comments",
+		"/blog#missing",
+		"page2.html",
+		"/draft",
+		"/old-blog",
+		"/removed/",
+		"/nothing",
+		"/files/price.pdf",
+		"#rec1",
+		"#popup:form",
+		"#order",
+		"#!/tproduct/123-456",
+		"/blog#!/tab/1-2",
+		"#absent",
+		"mailto:info@example.com?subject=Hi",
+		"mailto:?subject=Hi&body=https%3A%2F%2Fexample.com",
+		"mailto:info@example",
+		"tel:+7 (999) 123-45-67",
+		"tel:call-me",
+		"javascript:void(0)",
+		"https://other.example.org/ok",
+		"https://other.example.org/head",
+		"https://other.example.org/missing",
+	}
+	var body strings.Builder
+	for _, link := range links {
+		body.WriteString(`<a href="` + link + `">link</a>`)
+	}
+	pages := []tilda.PageExport{
+		{ID: "1", HTML: `<div id="rec1">` + body.String() + `</div>`},
+		{ID: "2", HTML: `<a name="comments"></a><a href="https://example.com">Home</a><area href="/not-found">`},
+	}
+	previous := &Manifest{Pages: []PageResult{
+		{ID: "2", Alias: "old-blog", Path: "page2.html"},
+		{ID: "5", Alias: "removed", Path: "page5.html"},
+	}}
+
+	checker := NewLinkChecker(project, WithPreviousManifests(previous), WithExternalLinks(nil), WithLinkConcurrency(2))
+	report, err := checker.Check(context.Background(), FetchedLinkPages(listing, pages))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"1": {"2", "4"},
+		"2": {"1", "3"},
+		"3": nil,
+		"4": nil,
+	}, report.Graph)
+
+	if assert.Len(t, report.Issues, 9) {
+		for i, want := range []LinkIssue{
+			{Kind: LinkMissingAnchor, PageID: "1", Href: "/blog#missing", TargetID: "2"},
+			{Kind: LinkUnpublished, PageID: "1", Href: "/draft", TargetID: "4"},
+			{Kind: LinkOldAlias, PageID: "1", Href: "/old-blog", TargetID: "2"},
+			{Kind: LinkDeletedPage, PageID: "1", Href: "/removed/", TargetID: "5"},
+			{Kind: LinkUnknownPage, PageID: "1", Href: "/nothing"},
+			{Kind: LinkMissingAnchor, PageID: "1", Href: "#absent", TargetID: "1"},
+			{Kind: LinkInvalidMailto, PageID: "1", Href: "mailto:info@example"},
+			{Kind: LinkInvalidTel, PageID: "1", Href: "tel:call-me"},
+			{Kind: LinkExternalBroken, PageID: "1", Href: "https://other.example.org/missing", Status: http.StatusNotFound},
+		} {
+			got := report.Issues[i]
+			got.Message = ""
+			assert.Equal(t, want, got)
+		}
+	}
+	assert.ErrorIs(t, report.Err(), ErrBrokenLinks)
+
+	// External links are not requested by default, previous aliases are unknown without previous manifests
+	httpmock.ZeroCallCounters()
+	report, err = NewLinkChecker(project).Check(context.Background(), FetchedLinkPages(listing, pages))
+	assert.NoError(t, err)
+	assert.Len(t, report.Issues, 8)
+	assert.Zero(t, httpmock.GetTotalCallCount())
+}
+
+func TestCheckLinks(t *testing.T) {
+	root := t.TempDir()
+	manifest := &Manifest{
+		Version: ManifestVersion,
+		Project: ManifestProject{ID: "54321", IndexpageID: "1"},
+		Pages: []PageResult{
+			{ID: "1", Path: "page1.html", Copies: []string{IndexFilename}},
+			{ID: "2", Alias: "blog", Path: "page2.html", Copies: []string{"blog/index.html"}},
+		},
+	}
+	assert.NoError(t, manifest.write(context.Background(), NewDirStorage(root)))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "page1.html"), []byte(`<a href="page2.html#top">Blog</a><a href="blog/index.html">Blog</a>`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "page2.html"), []byte(`<a href="index.html">Home</a><a href="page7.html">Old</a>`), 0o644))
+
+	report, err := CheckLinks(context.Background(), root)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"1": {"2"}, "2": {"1"}}, report.Graph)
+	assert.Equal(t, []LinkIssue{
+		{Kind: LinkUnknownPage, PageID: "2", Href: "page7.html", TargetID: "7"},
+	}, report.Issues)
+}
+
+func TestValidMailto(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "info@example.com"},
+		{value: "info@example.com,sales@example.com?subject=Hello%20world"},
+		{value: "Info%20%3Cinfo@example.com%3E"},
+		{value: "", wantErr: true},
+		{value: "?subject=Hello"},
+		{value: "?", wantErr: true},
+		{value: "info@example", wantErr: true},
+		{value: "info.example.com", wantErr: true},
+		{value: "info@example.com,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := validMailto(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidTel(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "+79991234567"},
+		{value: "+7 (999) 123-45-67"},
+		{value: "8%20800%20555-35-35"},
+		{value: "+1.555.123.4567;ext=12"},
+		{value: "", wantErr: true},
+		{value: "12", wantErr: true},
+		{value: "+7 999 CALL-NOW", wantErr: true},
+		{value: "1234567890123456", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			err := validTel(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// ManifestFilename is the file describing export contents written into the storage root
+const ManifestFilename = "manifest.json"
+
+// AssetMapFilename is the file with mapping of original asset paths to fingerprinted paths
+// written into the storage root when assets are fingerprinted
+const AssetMapFilename = "asset-map.json"
+
+// ManifestVersion is the version of manifest schema written by Exporter.
+// It is increased on incompatible changes of the schema.
+const ManifestVersion = 1
+
+// ErrManifestVersion is returned when manifest has schema version that is not supported
+var ErrManifestVersion = errors.New("unsupported manifest version")
+
+// Manifest describes everything export contains
+type Manifest struct {
+	Version int             `json:"version"` // Schema version (ManifestVersion)
+	Project ManifestProject `json:"project"` // Project information at the time of export
+	Pages   []PageResult    `json:"pages"`   // Exported pages in Sort order
+	Assets  []ManifestAsset `json:"assets"`  // Downloaded assets sorted by path
+}
+
+// ManifestProject represents project information in the manifest. The manifest is deployed with the site,
+// so it contains only public fields of tilda.ProjectInfo and never keys or credentials (FormsKey, ViewPassword, etc.).
+type ManifestProject struct {
+	ID           string         `json:"id"`
+	Title        string         `json:"title"`
+	Alias        string         `json:"alias"`
+	CustomDomain string         `json:"customdomain"`
+	URL          string         `json:"url"`
+	IndexpageID  string         `json:"indexpageid"`
+	Page404ID    string         `json:"page404id"`
+	Date         tilda.DateTime `json:"date"`
+}
+
+// ManifestAsset represents downloaded asset in the manifest
+type ManifestAsset struct {
+	URL          string         `json:"url"`                     // Source URL
+	Path         string         `json:"path"`                    // Path of the file in the storage
+	Size         int64          `json:"size"`                    // Size of the file in bytes
+	SHA256       string         `json:"sha256"`                  // Hex-encoded SHA-256 checksum of the file
+	ContentType  string         `json:"content_type"`            // MIME type of the file
+	Integrity    string         `json:"integrity,omitempty"`     // Subresource Integrity hash of scripts and styles
+	Width        int            `json:"width,omitempty"`         // Width of optimized image in pixels
+	OriginalSize int64          `json:"original_size,omitempty"` // Size of the image before optimization, savings are OriginalSize - Size
+	Variants     []ImageVariant `json:"variants,omitempty"`      // Downscaled variants of optimized image listed in srcset
+	Original     string         `json:"original,omitempty"`      // Path of the asset before fingerprinting (empty if the asset is not fingerprinted)
+}
+
+func newManifestProject(project tilda.ProjectInfo) ManifestProject {
+	return ManifestProject{
+		ID:           project.ID,
+		Title:        project.Title,
+		Alias:        project.Alias,
+		CustomDomain: project.CustomDomain,
+		URL:          project.URL,
+		IndexpageID:  project.IndexpageID,
+		Page404ID:    project.Page404ID,
+		Date:         project.Date,
+	}
+}
+
+// ProjectInfo returns project information with fields of the manifest project set
+func (p ManifestProject) ProjectInfo() tilda.ProjectInfo {
+	return tilda.ProjectInfo{
+		ID:           p.ID,
+		Title:        p.Title,
+		Alias:        p.Alias,
+		CustomDomain: p.CustomDomain,
+		URL:          p.URL,
+		IndexpageID:  p.IndexpageID,
+		Page404ID:    p.Page404ID,
+		Date:         p.Date,
+	}
+}
+
+// ReadManifest decodes manifest and checks its schema version
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("unmarshal manifest: %w", err)
+	}
+
+	if manifest.Version < 1 || manifest.Version > ManifestVersion {
+		return nil, fmt.Errorf("%w %d", ErrManifestVersion, manifest.Version)
+	}
+
+	return &manifest, nil
+}
+
+// LoadManifest reads manifest from the storage, the error wraps fs.ErrNotExist if there is no manifest
+func LoadManifest(ctx context.Context, storage Storage) (*Manifest, error) {
+	rc, err := storage.Get(ctx, ManifestFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ReadManifest(rc)
+}
+
+// Asset returns the asset with the path
+func (m *Manifest) Asset(name string) (ManifestAsset, bool) {
+	i := sort.Search(len(m.Assets), func(i int) bool {
+		return m.Assets[i].Path >= name
+	})
+	if i < len(m.Assets) && m.Assets[i].Path == name {
+		return m.Assets[i], true
+	}
+
+	return ManifestAsset{}, false
+}
+
+// Fingerprints returns mapping of original asset paths to paths of fingerprinted files
+func (m *Manifest) Fingerprints() map[string]string {
+	mapping := make(map[string]string)
+	for _, asset := range m.Assets {
+		if asset.Original != "" {
+			mapping[asset.Original] = asset.Path
+		}
+	}
+
+	return mapping
+}
+
+// write writes manifest into the storage
+func (m *Manifest) write(ctx context.Context, storage Storage) error {
+	bts, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	return storage.Put(ctx, ManifestFilename, bytes.NewReader(bts))
+}
+
+// writeAssetMap writes mapping of original asset paths to fingerprinted paths into the storage
+func (m *Manifest) writeAssetMap(ctx context.Context, storage Storage) error {
+	bts, err := json.MarshalIndent(m.Fingerprints(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal asset map: %w", err)
+	}
+
+	return storage.Put(ctx, AssetMapFilename, bytes.NewReader(bts))
+}
+
+// buildManifest describes assets listed in the export state: assets downloaded during this export
+// and assets kept from the previous export described in the previous manifest
+func buildManifest(result *Result, state *exportState, prev *Manifest) *Manifest {
+	known := make(map[string]ManifestAsset)
+	if prev != nil {
+		for _, asset := range prev.Assets {
+			known[asset.Path] = asset
+		}
+	}
+	for _, asset := range result.Assets {
+		manifestAsset := ManifestAsset{
+			URL:          asset.URL,
+			Path:         asset.StoragePath(),
+			Size:         asset.Size,
+			SHA256:       asset.SHA256,
+			ContentType:  asset.ContentType,
+			Integrity:    asset.Integrity,
+			Width:        asset.Width,
+			OriginalSize: asset.OriginalSize,
+			Variants:     asset.Variants,
+		}
+		if asset.FingerprintPath != "" {
+			manifestAsset.Original = asset.Path
+		}
+
+		known[manifestAsset.Path] = manifestAsset
+	}
+
+	manifest := &Manifest{
+		Version: ManifestVersion,
+		Project: newManifestProject(result.Project),
+		Pages:   result.Pages,
+		Assets:  []ManifestAsset{},
+	}
+	for name := range state.files() {
+		if asset, ok := known[name]; ok {
+			manifest.Assets = append(manifest.Assets, asset)
+		}
+	}
+	sort.Slice(manifest.Assets, func(i, j int) bool {
+		return manifest.Assets[i].Path < manifest.Assets[j].Path
+	})
+
+	return manifest
+}
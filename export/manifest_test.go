@@ -0,0 +1,144 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestProject_Manifest(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+
+	root := t.TempDir()
+	ctx := context.Background()
+	result, err := Project(ctx, newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+
+	manifest, err := LoadManifest(ctx, NewDirStorage(root))
+	assert.NoError(t, err)
+	assert.Equal(t, result.Manifest, manifest)
+
+	assert.Equal(t, ManifestVersion, manifest.Version)
+	assert.Equal(t, "54321", manifest.Project.ID)
+	if assert.Len(t, manifest.Pages, 2) {
+		assert.Equal(t, "2", manifest.Pages[1].ID)
+		assert.Equal(t, "blog", manifest.Pages[1].Alias)
+		assert.Equal(t, 1734259420, manifest.Pages[1].Published)
+		assert.Equal(t, "page2.html", manifest.Pages[1].Path)
+	}
+	assert.Equal(t, []ManifestAsset{
+		{
+			URL:         "https://static.tildacdn.com/css/fonts-tildasans.css",
+			Path:        "css/fonts-tildasans.css",
+			Size:        5,
+			SHA256:      sha256Hex("fonts"),
+			ContentType: "text/css; charset=utf-8",
+			Integrity:   tilda.IntegrityHash([]byte("fonts")),
+		}, {
+			URL:         "https://static.tildacdn.com/img/photo1.jpg",
+			Path:        "images/photo1.jpg",
+			Size:        7,
+			SHA256:      sha256Hex("photo 1"),
+			ContentType: "image/jpeg",
+		}, {
+			URL:         "https://static.tildacdn.com/img/photo2.jpg",
+			Path:        "images/photo2.jpg",
+			Size:        7,
+			SHA256:      sha256Hex("photo 2"),
+			ContentType: "image/jpeg",
+		}, {
+			URL:         "https://static.tildacdn.com/img/tildafavicon.ico",
+			Path:        "images/tildafavicon.ico",
+			Size:        7,
+			SHA256:      sha256Hex("favicon"),
+			ContentType: "image/x-icon",
+		}, {
+			URL:         "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js",
+			Path:        "js/tilda-scripts-3.0.min.js",
+			Size:        7,
+			SHA256:      sha256Hex("scripts"),
+			ContentType: "text/javascript; charset=utf-8",
+			Integrity:   tilda.IntegrityHash([]byte("scripts")),
+		},
+	}, manifest.Assets)
+
+	asset, ok := manifest.Asset("images/photo2.jpg")
+	assert.True(t, ok)
+	assert.Equal(t, sha256Hex("photo 2"), asset.SHA256)
+	_, ok = manifest.Asset("images/missing.jpg")
+	assert.False(t, ok)
+
+	// Unchanged pages keep their assets in the manifest of incremental export
+	result, err = Project(ctx, newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+	assert.Len(t, result.Assets, 1)
+	assert.Equal(t, manifest.Assets, result.Manifest.Assets)
+
+	bts, err := os.ReadFile(filepath.Join(root, ManifestFilename))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), `"sha256": "`+sha256Hex("photo 1")+`"`)
+}
+
+func TestProject_ManifestProject(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""))
+
+	root := t.TempDir()
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+	assert.Equal(t, "qwerty", result.Project.FormsKey)
+	assert.Equal(t, ManifestProject{ID: "54321", Title: "My Site", IndexpageID: "1", Page404ID: "3"}, result.Manifest.Project)
+
+	// The manifest is deployed with the site, so keys and credentials of the project must not leak into it
+	bts, err := os.ReadFile(filepath.Join(root, ManifestFilename))
+	assert.NoError(t, err)
+	var manifest struct {
+		Project map[string]any `json:"project"`
+	}
+	assert.NoError(t, json.Unmarshal(bts, &manifest))
+	assert.Equal(t, "54321", manifest.Project["id"])
+	for _, key := range []string{"formskey", "viewlogin", "viewpassword", "viewips"} {
+		assert.NotContains(t, manifest.Project, key)
+	}
+	assert.NotContains(t, string(bts), "qwerty")
+	assert.NotContains(t, string(bts), "secret")
+}
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{name: "current", data: `{"version":1,"project":{"id":"54321"},"pages":[],"assets":[]}`},
+		{name: "future", data: `{"version":2}`, wantErr: ErrManifestVersion},
+		{name: "missing version", data: `{}`, wantErr: ErrManifestVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := ReadManifest(strings.NewReader(tt.data))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "54321", manifest.Project.ID)
+		})
+	}
+
+	_, err := ReadManifest(strings.NewReader("{"))
+	assert.Error(t, err)
+}
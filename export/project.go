@@ -0,0 +1,517 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// Files written for special pages of the project
+const (
+	IndexFilename    = "index.html"
+	NotFoundFilename = "404.html"
+)
+
+// Exporter exports Tilda projects into the storage as static sites
+type Exporter struct {
+	client          *tilda.Client
+	storage         Storage
+	downloader      *Downloader
+	pageConcurrency int
+	robots          RobotsPolicy
+	fingerprint     bool
+	integrity       bool
+	images          *ImageOptions
+}
+
+// Result represents information about exported project
+type Result struct {
+	Project  tilda.ProjectInfo // Project information for export
+	Pages    []PageResult      // Exported pages in Sort order including pages unchanged since the previous export
+	Assets   []DownloadResult  // Assets downloaded for the project and changed pages
+	Removed  []string          // Files of deleted pages and orphaned assets removed from the storage
+	Manifest *Manifest         // Manifest written into ManifestFilename
+}
+
+// PageResult represents information about exported page
+type PageResult struct {
+	ID        string         `json:"id"`
+	Title     string         `json:"title"`
+	Alias     string         `json:"alias,omitempty"`
+	Published int            `json:"published"`
+	Date      tilda.DateTime `json:"date"`
+	Path      string         `json:"path"`               // Path of the page file (Filename)
+	Copies    []string       `json:"copies,omitempty"`   // Paths of page copies: alias path, index.html for index page and 404.html for 404 page
+	Unmapped  []string       `json:"unmapped,omitempty"` // Tilda CDN URLs left in HTML code because they are not listed in page assets
+	Unchanged bool           `json:"-"`                  // Page was not published since the previous export, so it was not fetched again
+}
+
+// NewExporter creates new exporter of projects available to client into the storage
+func NewExporter(client *tilda.Client, storage Storage, options ...func(*Exporter)) *Exporter {
+	exporter := &Exporter{
+		client:          client,
+		storage:         storage,
+		pageConcurrency: 1,
+	}
+
+	for _, o := range options {
+		o(exporter)
+	}
+
+	if exporter.downloader == nil {
+		exporter.downloader = NewDownloader(storage)
+	}
+	exporter.fingerprint = exporter.fingerprint || exporter.downloader.fingerprint
+
+	return exporter
+}
+
+// WithPageConcurrency option allows to set max number of simultaneous page requests to Tilda API
+func WithPageConcurrency(concurrency int) func(*Exporter) {
+	return func(e *Exporter) {
+		e.pageConcurrency = max(concurrency, 1)
+	}
+}
+
+// WithDownloader option allows to set custom downloader of assets
+func WithDownloader(downloader *Downloader) func(*Exporter) {
+	return func(e *Exporter) {
+		e.downloader = downloader
+	}
+}
+
+// WithRobots option allows to override search setting of the project (e.g. to forbid indexing of staging mirror)
+func WithRobots(policy RobotsPolicy) func(*Exporter) {
+	return func(e *Exporter) {
+		e.robots = policy
+	}
+}
+
+// WithAssetFingerprints option allows to write assets under content-hashed names (see FingerprintPath) instead of
+// relying on query strings of Tilda URLs, so they can be served with immutable caching (see ImmutableCacheControl).
+// References in HTML code and url() expressions in styles are rewritten to fingerprinted names.
+func WithAssetFingerprints() func(*Exporter) {
+	return func(e *Exporter) {
+		e.fingerprint = true
+	}
+}
+
+// WithIntegrity option allows to add integrity attributes with SHA-384 hashes of downloaded scripts and styles
+// and crossorigin attributes to script and stylesheet tags of pages (see SetIntegrity)
+func WithIntegrity() func(*Exporter) {
+	return func(e *Exporter) {
+		e.integrity = true
+	}
+}
+
+// Project exports the project into dest storage as static site
+func Project(ctx context.Context, client *tilda.Client, projectID string, dest Storage, options ...func(*Exporter)) (*Result, error) {
+	return NewExporter(client, dest, options...).Project(ctx, projectID)
+}
+
+// Project exports every page of the project with full HTML code as Filename, copies pages to their alias paths,
+// index page to index.html and 404 page to 404.html, and downloads images of the project and assets of all pages.
+// Asset URLs in HTML code are replaced with relative paths of downloaded files.
+// robots.txt and robots meta tags of pages follow search setting of the project unless overridden with WithRobots.
+// With WithAssetFingerprints mapping of original asset paths to fingerprinted paths is written into AssetMapFilename.
+//
+// Export is incremental: the state of exported pages is kept in StateFilename, and only pages published
+// since the previous export are fetched again. Files of deleted pages and assets no longer used by any page are removed.
+// Failed pages and assets don't stop export and are returned joined after all other files are written.
+func (e *Exporter) Project(ctx context.Context, projectID string) (*Result, error) {
+	project, err := e.client.GetProjectExport(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("get project export: %w", err)
+	}
+
+	listing, err := e.client.GetProjectPages(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("get project pages: %w", err)
+	}
+	sort.SliceStable(listing, func(i, j int) bool {
+		return listing[i].Sort < listing[j].Sort
+	})
+
+	prev, err := loadState(ctx, e.storage)
+	if err != nil {
+		return nil, fmt.Errorf("load state: %w", err)
+	}
+
+	prevManifest, err := LoadManifest(ctx, e.storage)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, ErrManifestVersion) {
+		return nil, fmt.Errorf("load manifest: %w", err)
+	}
+
+	robots := e.robots.mode(NoSearch(project))
+
+	var changed []tilda.Page
+	for _, page := range listing {
+		pageState, ok := prev.Pages[page.ID]
+		if !ok || pageState.Published != page.Published || prev.Robots != robots || prev.Fingerprints != e.fingerprint || prev.Integrity != e.integrity || prev.Images != e.images.stateKey() ||
+			!slices.Equal(pageState.Result.Copies, pageCopies(project, page.ID, page.Alias)) {
+			changed = append(changed, page)
+		}
+	}
+
+	pages, pagesErr := e.client.GetPagesExport(ctx, changed, tilda.BulkOptions{
+		Concurrency: e.pageConcurrency,
+		Full:        true,
+	})
+	var bulkErr *tilda.BulkError
+	if pagesErr != nil && !errors.As(pagesErr, &bulkErr) {
+		return nil, fmt.Errorf("get pages export: %w", pagesErr)
+	}
+
+	assets := projectAssets(project)
+	for _, page := range pages {
+		assets = append(assets, PageAssets(page)...)
+	}
+
+	// Assets are downloaded before pages are written, so that pages refer to fingerprinted names
+	previous := prev.downloads(e.fingerprint, e.images.stateKey())
+	downloaded, assetsErr := e.download(ctx, assets, previous)
+	results := make(map[string]DownloadResult, len(downloaded))
+	for _, asset := range downloaded {
+		results[asset.Path] = asset
+	}
+	storagePath := func(asset Asset) string {
+		if result, ok := results[asset.Path]; ok {
+			return result.StoragePath()
+		}
+
+		return asset.Path
+	}
+
+	written := make(map[string]PageResult, len(pages))
+	fetched := make(map[string]tilda.PageExport, len(pages))
+	for _, page := range pages {
+		pageResult, err := e.writePage(ctx, project, page, robots, results)
+		if err != nil {
+			return nil, fmt.Errorf("write page %s: %w", page.ID, err)
+		}
+
+		written[page.ID] = pageResult
+		fetched[page.ID] = page
+	}
+
+	result := &Result{
+		Project: project,
+		Pages:   make([]PageResult, 0, len(listing)),
+		Assets:  downloaded,
+	}
+
+	next := newExportState()
+	next.Robots = robots
+	next.Fingerprints = e.fingerprint
+	next.Integrity = e.integrity
+	next.Images = e.images.stateKey()
+	for _, asset := range projectAssets(project) {
+		next.ProjectAssets[storagePath(asset)] = results[asset.Path].SHA256
+		for _, variant := range results[asset.Path].Variants {
+			next.ProjectAssets[variant.Path] = variant.SHA256
+		}
+	}
+	next.Downloads = downloadsState(previous, downloaded)
+	for _, page := range listing {
+		if pageResult, ok := written[page.ID]; ok {
+			pageState := pageState{
+				Published: page.Published,
+				Result:    pageResult,
+				Assets:    make(map[string]string),
+			}
+			for _, asset := range PageAssets(fetched[page.ID]) {
+				downloadResult, ok := results[asset.Path]
+				if !ok {
+					pageState.Published = 0
+				}
+				pageState.Assets[storagePath(asset)] = downloadResult.SHA256
+				for _, variant := range downloadResult.Variants {
+					pageState.Assets[variant.Path] = variant.SHA256
+				}
+			}
+
+			next.Pages[page.ID] = pageState
+			result.Pages = append(result.Pages, pageResult)
+
+			continue
+		}
+
+		// Keep files of unchanged pages and pages failed to fetch this time
+		if pageState, ok := prev.Pages[page.ID]; ok {
+			next.Pages[page.ID] = pageState
+			if !slices.ContainsFunc(changed, func(p tilda.Page) bool { return p.ID == page.ID }) {
+				pageResult := pageState.Result
+				pageResult.Unchanged = true
+				result.Pages = append(result.Pages, pageResult)
+			}
+		}
+	}
+
+	// Results of assets no longer used by any page are not kept
+	files := next.files()
+	for name, download := range next.Downloads {
+		if !files[download.StoragePath()] {
+			delete(next.Downloads, name)
+		}
+	}
+
+	for _, name := range removedFiles(prev, next) {
+		if err := e.storage.Delete(ctx, name); err != nil {
+			return nil, fmt.Errorf("delete %s: %w", name, err)
+		}
+
+		result.Removed = append(result.Removed, name)
+	}
+
+	if store := e.downloader.store; store != nil {
+		refAssets(store, project, pages, downloaded)
+		for pageID := range prev.Pages {
+			if _, ok := next.Pages[pageID]; !ok {
+				store.Release(PageOwner(project.ID, pageID))
+			}
+		}
+
+		if err := store.Save(ctx); err != nil {
+			return nil, fmt.Errorf("save content store: %w", err)
+		}
+	}
+
+	if err := e.storage.Put(ctx, RobotsFilename, bytes.NewReader(RobotsTxt(robots == robotsNoIndex, ""))); err != nil {
+		return nil, fmt.Errorf("put %s: %w", RobotsFilename, err)
+	}
+
+	result.Manifest = buildManifest(result, next, prevManifest)
+	if err := result.Manifest.write(ctx, e.storage); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+
+	switch {
+	case e.fingerprint:
+		if err := result.Manifest.writeAssetMap(ctx, e.storage); err != nil {
+			return nil, fmt.Errorf("write asset map: %w", err)
+		}
+	case prev.Fingerprints:
+		if err := e.storage.Delete(ctx, AssetMapFilename); err != nil {
+			return nil, fmt.Errorf("delete %s: %w", AssetMapFilename, err)
+		}
+
+		result.Removed = append(result.Removed, AssetMapFilename)
+	}
+
+	if err := next.save(ctx, e.storage); err != nil {
+		return nil, fmt.Errorf("save state: %w", err)
+	}
+
+	return result, errors.Join(pagesErr, assetsErr)
+}
+
+// download downloads assets. Assets not changed since the previous export (previous results) are requested
+// conditionally. Images are optimized with WithImageOptimization and their variants are written.
+// With fingerprints styles are downloaded after other assets, so that url() references in styles
+// are rewritten to fingerprinted names of downloaded files.
+func (e *Exporter) download(ctx context.Context, assets []Asset, previous []DownloadResult) ([]DownloadResult, error) {
+	downloader := *e.downloader
+	downloader.fingerprint = e.fingerprint
+	WithPreviousResults(previous)(&downloader)
+	if !e.fingerprint && e.images == nil {
+		return downloader.Download(ctx, assets)
+	}
+
+	var optimizer *imageOptimizer
+	if e.images != nil {
+		optimizer = newImageOptimizer(*e.images)
+		downloader.transform = optimizer.transform
+	}
+
+	var styles, other []Asset
+	for _, asset := range assets {
+		if e.fingerprint && strings.EqualFold(path.Ext(asset.Path), ".css") {
+			styles = append(styles, asset)
+		} else {
+			other = append(other, asset)
+		}
+	}
+
+	downloaded, err := downloader.Download(ctx, other)
+	if optimizer != nil {
+		err = errors.Join(err, optimizer.writeVariants(ctx, e.storage, downloaded, e.fingerprint))
+	}
+	if len(styles) == 0 {
+		return downloaded, err
+	}
+
+	mapping := make(map[string]string, len(downloaded))
+	for _, result := range downloaded {
+		mapping[result.URL] = result.StoragePath()
+	}
+
+	// Rewritten styles depend on other assets, so they are never taken from the previous export
+	rewriter := NewRewriter(mapping)
+	downloader.previous = nil
+	downloader.transform = func(asset Asset, body []byte) ([]byte, error) {
+		return []byte(rewriter.rewriteCSS(string(body), RelativePrefix(asset.Path), make(map[string]bool))), nil
+	}
+	downloadedStyles, stylesErr := downloader.Download(ctx, styles)
+
+	return append(downloaded, downloadedStyles...), errors.Join(err, stylesErr)
+}
+
+// downloadsState returns download results kept in the state: previous results updated with downloaded assets
+func downloadsState(previous, downloaded []DownloadResult) map[string]DownloadResult {
+	downloads := make(map[string]DownloadResult, len(previous)+len(downloaded))
+	for _, result := range previous {
+		downloads[result.Path] = result
+	}
+	for _, result := range downloaded {
+		result.Unchanged = false
+		downloads[result.Path] = result
+	}
+
+	return downloads
+}
+
+// writePage writes the page and its copies. Asset URLs are replaced with storage paths of downloaded assets
+// (results by asset paths). Without fingerprints, URLs of assets failed to download are replaced with asset paths too.
+func (e *Exporter) writePage(ctx context.Context, project tilda.ProjectInfo, page tilda.PageExport, robots string, results map[string]DownloadResult) (PageResult, error) {
+	result := PageResult{
+		ID:        page.ID,
+		Title:     page.Title,
+		Alias:     page.Alias,
+		Published: page.Published,
+		Date:      page.Date,
+		Path:      page.Filename,
+		Copies:    pageCopies(project, page.ID, page.Alias),
+	}
+	if result.Path == "" {
+		result.Path = "page" + page.ID + ".html"
+	}
+
+	pageHTML := page.HTML
+	if robots != robotsKeep {
+		var err error
+		if pageHTML, err = SetMetaRobots(pageHTML, robots == robotsNoIndex); err != nil {
+			return PageResult{}, fmt.Errorf("set robots meta tag: %w", err)
+		}
+	}
+
+	assets := append(projectAssets(project), PageAssets(page)...)
+	mapping := make(map[string]string)
+	integrity := make(map[string]string)
+	for _, asset := range assets {
+		if result, ok := results[asset.Path]; ok {
+			mapping[asset.URL] = result.StoragePath()
+			if result.Integrity != "" {
+				integrity[asset.URL] = result.Integrity
+			}
+		} else if !e.fingerprint {
+			mapping[asset.URL] = asset.Path
+		}
+	}
+
+	srcsets, variants := imageSrcsets(assets, results)
+	for from, to := range variants {
+		mapping[from] = to
+	}
+	if len(srcsets) > 0 {
+		var err error
+		if pageHTML, err = SetSrcset(pageHTML, srcsets); err != nil {
+			return PageResult{}, fmt.Errorf("set srcset: %w", err)
+		}
+	}
+
+	if e.integrity {
+		var err error
+		if pageHTML, err = SetIntegrity(pageHTML, integrity, tilda.CrossOriginAnonymous); err != nil {
+			return PageResult{}, fmt.Errorf("set integrity: %w", err)
+		}
+	}
+
+	rewriter := NewRewriter(mapping)
+	for _, name := range append([]string{result.Path}, result.Copies...) {
+		rewritten, err := rewriter.Rewrite(pageHTML, RelativePrefix(name))
+		if err != nil {
+			return PageResult{}, fmt.Errorf("rewrite %s: %w", name, err)
+		}
+
+		if err := e.storage.Put(ctx, name, strings.NewReader(rewritten.HTML)); err != nil {
+			return PageResult{}, fmt.Errorf("put %s: %w", name, err)
+		}
+
+		result.Unmapped = rewritten.Unmapped
+	}
+
+	return result, nil
+}
+
+// pageCopies returns paths of page copies: alias path, index.html for index page and 404.html for 404 page
+func pageCopies(project tilda.ProjectInfo, pageID, alias string) []string {
+	var copies []string
+	if aliasPath, ok := AliasPath(alias); ok {
+		copies = append(copies, aliasPath)
+	}
+	if isPageID(project.IndexpageID) && pageID == project.IndexpageID {
+		copies = append(copies, IndexFilename)
+	}
+	if isPageID(project.Page404ID) && pageID == project.Page404ID {
+		copies = append(copies, NotFoundFilename)
+	}
+
+	return copies
+}
+
+// AliasPath returns path of the file serving the page alias (e.g. blog/index.html for "blog" alias)
+func AliasPath(alias string) (string, bool) {
+	alias = strings.Trim(alias, "/")
+	if alias == "" || !fs.ValidPath(alias) {
+		return "", false
+	}
+
+	return path.Join(alias, IndexFilename), true
+}
+
+// projectAssets returns images of the project (e.g. favicon) placed under export path of the project
+func projectAssets(project tilda.ProjectInfo) []Asset {
+	assets := make([]Asset, 0, len(project.Images))
+	for _, img := range project.Images {
+		assets = append(assets, Asset{URL: img.From, Path: path.Join(exportDir(project.ExportImgPath, DefaultImgPath), img.To)})
+	}
+
+	return assets
+}
+
+// refAssets records downloaded assets referenced by the project and each of its pages in the content store
+func refAssets(store *ContentStore, project tilda.ProjectInfo, pages []tilda.PageExport, downloaded []DownloadResult) {
+	sums := make(map[string]string, len(downloaded))
+	for _, result := range downloaded {
+		sums[result.Path] = result.SHA256
+	}
+
+	owned := func(assets []Asset) []string {
+		var refs []string
+		for _, asset := range assets {
+			if sum, ok := sums[asset.Path]; ok {
+				refs = append(refs, sum)
+			}
+		}
+
+		return refs
+	}
+
+	store.SetRefs(ProjectOwner(project.ID), owned(projectAssets(project)))
+	for _, page := range pages {
+		store.SetRefs(PageOwner(page.ProjectID, page.ID), owned(PageAssets(page)))
+	}
+}
+
+// isPageID reports whether the value of ProjectInfo field refers to the page (Tilda uses "0" for not set pages)
+func isPageID(id string) bool {
+	return id != "" && id != "0"
+}
@@ -0,0 +1,326 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+const testAPIBaseURL = "https://api.tildacdn.info"
+
+func newTestClient() *tilda.Client {
+	return tilda.NewClient(&tilda.Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+}
+
+func registerAPI(endpoint string, params string, result any) {
+	url := fmt.Sprintf("%s/v1/%s/?%s&publickey=public&secretkey=secret", testAPIBaseURL, endpoint, params)
+	httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewJsonResponderOrPanic(http.StatusOK, tilda.NewResponse(result)))
+}
+
+func registerAsset(url, body string) {
+	httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewStringResponder(http.StatusOK, body))
+}
+
+func testPage(id string, sort int, alias string) tilda.PageExport {
+	return tilda.PageExport{
+		ID:        id,
+		ProjectID: "54321",
+		Title:     "Page " + id,
+		Alias:     alias,
+		Sort:      sort,
+		Published: 1734259400 + sort,
+		Date:      tilda.DateTime(time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC)),
+		Filename:  "page" + id + ".html",
+		HTML:      `<!DOCTYPE html><html><head><link rel="stylesheet" href="https://static.tildacdn.com/css/fonts-tildasans.css"></head><body><div id="allrecords" data-tilda-page-id="` + id + `"><img src="https://static.tildacdn.com/img/photo` + id + `.jpg"></div></body></html>`,
+		Images:    []tilda.Image{{From: "https://static.tildacdn.com/img/photo" + id + ".jpg", To: "photo" + id + ".jpg"}},
+		JS:        []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js", Attrs: []string{"defer"}}},
+		CSS:       []tilda.CSS{{From: "https://static.tildacdn.com/css/fonts-tildasans.css", To: "fonts-tildasans.css"}},
+	}
+}
+
+func registerTestProject(pages ...tilda.PageExport) {
+	registerAPI("getprojectexport", "projectid=54321", tilda.ProjectInfo{
+		ID:           "54321",
+		Title:        "My Site",
+		IndexpageID:  "1",
+		Page404ID:    "3",
+		ViewLogin:    "guest",
+		ViewPassword: "secret",
+		ViewIPs:      "127.0.0.1",
+		FormsKey:     "qwerty",
+		Images:       []tilda.Image{{From: "https://static.tildacdn.com/img/tildafavicon.ico", To: "tildafavicon.ico"}},
+	})
+
+	listing := make([]tilda.Page, 0, len(pages))
+	for _, page := range pages {
+		listing = append(listing, tilda.Page{ID: page.ID, Sort: page.Sort, Published: page.Published, Alias: page.Alias})
+		registerAPI("getpagefullexport", "pageid="+page.ID, page)
+		registerAsset("https://static.tildacdn.com/img/photo"+page.ID+".jpg", "photo "+page.ID)
+	}
+	registerAPI("getpageslist", "projectid=54321", listing)
+
+	registerAsset("https://static.tildacdn.com/img/tildafavicon.ico", "favicon")
+	registerAsset("https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", "scripts")
+	registerAsset("https://static.tildacdn.com/css/fonts-tildasans.css", "fonts")
+}
+
+func TestProject(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("2", 20, "blog"), testPage("1", 10, ""), testPage("3", 30, "not-found"))
+
+	root := t.TempDir()
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithPageConcurrency(2))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "My Site", result.Project.Title)
+	assert.Equal(t, []PageResult{
+		{
+			ID:        "1",
+			Title:     "Page 1",
+			Published: 1734259410,
+			Date:      tilda.DateTime(time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC)),
+			Path:      "page1.html",
+			Copies:    []string{"index.html"},
+		}, {
+			ID:        "2",
+			Title:     "Page 2",
+			Alias:     "blog",
+			Published: 1734259420,
+			Date:      tilda.DateTime(time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC)),
+			Path:      "page2.html",
+			Copies:    []string{"blog/index.html"},
+		}, {
+			ID:        "3",
+			Title:     "Page 3",
+			Alias:     "not-found",
+			Published: 1734259430,
+			Date:      tilda.DateTime(time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC)),
+			Path:      "page3.html",
+			Copies:    []string{"not-found/index.html", "404.html"},
+		},
+	}, result.Pages)
+	assert.Len(t, result.Assets, 6)
+
+	for name, want := range map[string]string{
+		"images/tildafavicon.ico":     "favicon",
+		"images/photo1.jpg":           "photo 1",
+		"images/photo2.jpg":           "photo 2",
+		"js/tilda-scripts-3.0.min.js": "scripts",
+		"css/fonts-tildasans.css":     "fonts",
+	} {
+		bts, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(bts))
+	}
+
+	for _, name := range []string{"page1.html", "index.html", "page2.html", "blog/index.html", "page3.html", "404.html"} {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(name)))
+		assert.NoError(t, err, name)
+	}
+
+	bts, err := os.ReadFile(filepath.Join(root, "blog", "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<!DOCTYPE html><html><head><link rel="stylesheet" href="../css/fonts-tildasans.css"></head><body><div id="allrecords" data-tilda-page-id="2"><img src="../images/photo2.jpg"></div></body></html>`, string(bts))
+
+	bts, err = os.ReadFile(filepath.Join(root, "page2.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<!DOCTYPE html><html><head><link rel="stylesheet" href="css/fonts-tildasans.css"></head><body><div id="allrecords" data-tilda-page-id="2"><img src="images/photo2.jpg"></div></body></html>`, string(bts))
+
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET https://static.tildacdn.com/css/fonts-tildasans.css"])
+}
+
+func TestProject_Incremental(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"), testPage("3", 30, "not-found"))
+
+	root := t.TempDir()
+	ctx := context.Background()
+	_, err := Project(ctx, newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(root, StateFilename))
+	assert.NoError(t, err)
+
+	republished := testPage("2", 20, "news")
+	republished.Published++
+	republished.HTML = `<div id="allrecords"><img src="https://static.tildacdn.com/img/cover.jpg"></div>`
+	republished.Images = []tilda.Image{{From: "https://static.tildacdn.com/img/cover.jpg", To: "cover.jpg"}}
+	registerTestProject(testPage("1", 10, ""), republished)
+	registerAsset("https://static.tildacdn.com/img/cover.jpg", "cover")
+	httpmock.ZeroCallCounters()
+
+	result, err := Project(ctx, newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+
+	if assert.Len(t, result.Pages, 2) {
+		assert.Equal(t, "1", result.Pages[0].ID)
+		assert.True(t, result.Pages[0].Unchanged)
+		assert.Equal(t, "2", result.Pages[1].ID)
+		assert.False(t, result.Pages[1].Unchanged)
+		assert.Equal(t, []string{"news/index.html"}, result.Pages[1].Copies)
+	}
+	assert.Equal(t, []string{
+		"404.html",
+		"blog/index.html",
+		"images/photo2.jpg",
+		"images/photo3.jpg",
+		"not-found/index.html",
+		"page3.html",
+	}, result.Removed)
+
+	for _, name := range result.Removed {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(name)))
+		assert.ErrorIs(t, err, fs.ErrNotExist, name)
+	}
+	for _, name := range []string{"index.html", "page1.html", "images/photo1.jpg", "news/index.html", "images/cover.jpg", "css/fonts-tildasans.css"} {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(name)))
+		assert.NoError(t, err, name)
+	}
+
+	info := httpmock.GetCallCountInfo()
+	assert.Equal(t, 0, info["GET "+testAPIBaseURL+"/v1/getpagefullexport/?pageid=1&publickey=public&secretkey=secret"])
+	assert.Equal(t, 1, info["GET "+testAPIBaseURL+"/v1/getpagefullexport/?pageid=2&publickey=public&secretkey=secret"])
+	assert.Equal(t, 0, info["GET https://static.tildacdn.com/img/photo1.jpg"])
+}
+
+func TestProject_IncrementalConditional(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var conditional []string
+	scripts := func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-None-Match") == `"scripts"` {
+			conditional = append(conditional, req.URL.String())
+			return httpmock.NewStringResponse(http.StatusNotModified, ""), nil
+		}
+
+		resp := httpmock.NewStringResponse(http.StatusOK, "scripts")
+		resp.Header.Set("ETag", `"scripts"`)
+		return resp, nil
+	}
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", scripts)
+
+	root := t.TempDir()
+	ctx := context.Background()
+	_, err := Project(ctx, newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+
+	republished := testPage("2", 20, "blog")
+	republished.Published++
+	registerTestProject(testPage("1", 10, ""), republished)
+	httpmock.RegisterResponder(http.MethodGet, "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", scripts)
+
+	result, err := Project(ctx, newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"}, conditional)
+
+	for _, asset := range result.Assets {
+		if asset.Path == "js/tilda-scripts-3.0.min.js" {
+			assert.True(t, asset.Unchanged)
+			assert.Equal(t, sha256Hex("scripts"), asset.SHA256)
+		}
+	}
+	bts, err := os.ReadFile(filepath.Join(root, "js", "tilda-scripts-3.0.min.js"))
+	assert.NoError(t, err)
+	assert.Equal(t, "scripts", string(bts))
+	assert.Empty(t, result.Removed)
+}
+
+func TestProject_IncrementalIndexChanged(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+
+	root := t.TempDir()
+	_, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+
+	registerAPI("getprojectexport", "projectid=54321", tilda.ProjectInfo{ID: "54321", IndexpageID: "2"})
+
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"images/tildafavicon.ico"}, result.Removed)
+
+	if assert.Len(t, result.Pages, 2) {
+		assert.False(t, result.Pages[0].Unchanged)
+		assert.Empty(t, result.Pages[0].Copies)
+		assert.Equal(t, []string{"blog/index.html", "index.html"}, result.Pages[1].Copies)
+	}
+
+	bts, err := os.ReadFile(filepath.Join(root, "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), `data-tilda-page-id="2"`)
+}
+
+func TestProject_PageFailed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+	httpmock.RegisterResponder(http.MethodGet,
+		fmt.Sprintf("%s/v1/getpagefullexport/?pageid=2&publickey=public&secretkey=secret", testAPIBaseURL),
+		httpmock.NewStringResponder(http.StatusInternalServerError, ""))
+
+	root := t.TempDir()
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root))
+
+	var bulkErr *tilda.BulkError
+	if assert.ErrorAs(t, err, &bulkErr) {
+		assert.Equal(t, "2", bulkErr.Errors[0].PageID)
+	}
+	assert.Len(t, result.Pages, 1)
+
+	_, err = os.Stat(filepath.Join(root, "index.html"))
+	assert.NoError(t, err)
+}
+
+func TestProject_ProjectFailed(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet,
+		fmt.Sprintf("%s/v1/getprojectexport/?projectid=54321&publickey=public&secretkey=secret", testAPIBaseURL),
+		httpmock.NewStringResponder(http.StatusOK, `{"status":"ERROR"}`))
+
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(t.TempDir()))
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestAliasPath(t *testing.T) {
+	tests := []struct {
+		alias  string
+		want   string
+		wantOk bool
+	}{
+		{alias: "blog", want: "blog/index.html", wantOk: true},
+		{alias: "/blog/post/", want: "blog/post/index.html", wantOk: true},
+		{alias: ""},
+		{alias: "../blog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			got, ok := AliasPath(tt.alias)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// CDNHosts are domains of Tilda CDN; references to them that have no local mapping are reported by Rewriter
+var CDNHosts = []string{"tildacdn.com", "tildacdn.info"}
+
+// urlAttrs are attributes containing single asset URL
+var urlAttrs = map[string]bool{
+	"src":           true,
+	"href":          true,
+	"poster":        true,
+	"data-src":      true,
+	"data-original": true,
+	"data-bgimgurl": true,
+
+	"data-content-cover-bg": true,
+}
+
+// srcsetAttrs are attributes containing comma-separated list of URLs with descriptors
+var srcsetAttrs = map[string]bool{
+	"srcset":      true,
+	"data-srcset": true,
+}
+
+var cssURLRegexp = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+
+// Rewriter replaces asset URLs in HTML code with paths of local files
+type Rewriter struct {
+	mapping map[string]string
+}
+
+// RewriteResult represents HTML code with rewritten asset URLs
+type RewriteResult struct {
+	HTML     string
+	Unmapped []string // Sorted Tilda CDN URLs that have no local mapping
+}
+
+// NewRewriter creates new rewriter replacing source URLs (keys of mapping) with local paths (values of mapping)
+func NewRewriter(mapping map[string]string) *Rewriter {
+	return &Rewriter{mapping: normalizeURLs(mapping)}
+}
+
+// AssetMapping returns mapping of asset URLs to their paths in the storage
+func AssetMapping(assets []Asset) map[string]string {
+	mapping := make(map[string]string, len(assets))
+	for _, asset := range assets {
+		mapping[asset.URL] = asset.Path
+	}
+
+	return mapping
+}
+
+// PageMapping returns mapping of URLs of page assets to their paths in the storage
+func PageMapping(page tilda.PageExport) map[string]string {
+	return AssetMapping(PageAssets(page))
+}
+
+// RelativePrefix returns prefix that makes paths relative to the storage root usable from the file
+// (e.g. "../" for blog/index.html)
+func RelativePrefix(name string) string {
+	return strings.Repeat("../", strings.Count(path.Clean(name), "/"))
+}
+
+// Rewrite replaces asset URLs in src, href, srcset, lazy-load attributes, inline styles and style elements.
+// Local paths are prefixed with prefix (see RelativePrefix). Other markup is kept as is.
+func (r *Rewriter) Rewrite(htmlCode string, prefix string) (RewriteResult, error) {
+	var out bytes.Buffer
+	unmapped := make(map[string]bool)
+
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlCode))
+	var inStyle bool
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return RewriteResult{}, fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return RewriteResult{HTML: out.String(), Unmapped: sortedKeys(unmapped)}, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(tokenizer.Raw())
+			token := tokenizer.Token()
+			inStyle = tokenType == html.StartTagToken && token.Data == "style"
+
+			changed := false
+			for i, attr := range token.Attr {
+				value := r.rewriteAttr(attr.Key, attr.Val, prefix, unmapped)
+				if value != attr.Val {
+					token.Attr[i].Val = value
+					changed = true
+				}
+			}
+
+			if changed {
+				out.WriteString(token.String())
+			} else {
+				out.WriteString(raw)
+			}
+		case html.EndTagToken:
+			inStyle = false
+			out.Write(tokenizer.Raw())
+		case html.TextToken:
+			raw := string(tokenizer.Raw())
+			if inStyle {
+				raw = r.rewriteCSS(raw, prefix, unmapped)
+			}
+			out.WriteString(raw)
+		default:
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
+
+func (r *Rewriter) rewriteAttr(key, value, prefix string, unmapped map[string]bool) string {
+	switch {
+	case urlAttrs[key]:
+		return r.rewriteURL(value, prefix, unmapped)
+	case srcsetAttrs[key]:
+		changed := false
+		candidates := strings.Split(value, ",")
+		for i, candidate := range candidates {
+			fields := strings.Fields(candidate)
+			if len(fields) == 0 {
+				continue
+			}
+
+			if rewritten := r.rewriteURL(fields[0], prefix, unmapped); rewritten != fields[0] {
+				candidates[i] = strings.Replace(candidate, fields[0], rewritten, 1)
+				changed = true
+			}
+		}
+
+		if !changed {
+			return value
+		}
+
+		return strings.Join(candidates, ",")
+	case key == "style":
+		return r.rewriteCSS(value, prefix, unmapped)
+	}
+
+	return value
+}
+
+func (r *Rewriter) rewriteCSS(css, prefix string, unmapped map[string]bool) string {
+	return cssURLRegexp.ReplaceAllStringFunc(css, func(match string) string {
+		m := cssURLRegexp.FindStringSubmatch(match)
+		rewritten := r.rewriteURL(m[2], prefix, unmapped)
+		if rewritten == m[2] {
+			return match
+		}
+
+		return "url(" + m[1] + rewritten + m[3] + ")"
+	})
+}
+
+func (r *Rewriter) rewriteURL(value, prefix string, unmapped map[string]bool) string {
+	if local, ok := lookupURL(r.mapping, value); ok {
+		return prefix + local
+	}
+
+	if IsCDNURL(value) {
+		unmapped[strings.TrimSpace(value)] = true
+	}
+
+	return value
+}
+
+// normalizeURLs returns copy of the map keyed by URLs with schemes removed (see lookupURL)
+func normalizeURLs(m map[string]string) map[string]string {
+	normalized := make(map[string]string, len(m))
+	for from, value := range m {
+		normalized[normalizeURL(from)] = value
+	}
+
+	return normalized
+}
+
+// lookupURL returns value of the URL in the map returned by normalizeURLs. URLs with query string or fragment
+// fall back to the value of the URL without them (e.g. tilda-blocks-page1.min.css?t=1734259633).
+func lookupURL(normalized map[string]string, value string) (string, bool) {
+	key := normalizeURL(value)
+	if v, ok := normalized[key]; ok {
+		return v, true
+	}
+
+	if i := strings.IndexAny(key, "?#"); i >= 0 {
+		v, ok := normalized[key[:i]]
+		return v, ok
+	}
+
+	return "", false
+}
+
+// IsCDNURL reports whether URL refers to Tilda CDN
+func IsCDNURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, cdnHost := range CDNHosts {
+		if host == cdnHost || strings.HasSuffix(host, "."+cdnHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeURL removes scheme from URL so that http, https and protocol-relative URLs match each other
+func normalizeURL(value string) string {
+	value = strings.TrimSpace(value)
+	for _, scheme := range []string{"https:", "http:"} {
+		if len(value) >= len(scheme) && strings.EqualFold(value[:len(scheme)], scheme) {
+			return value[len(scheme):]
+		}
+	}
+
+	return value
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestRewriter_Rewrite(t *testing.T) {
+	rewriter := NewRewriter(map[string]string{
+		"https://static.tildacdn.com/img/photo.jpg":                                              "images/photo.jpg",
+		"https://static.tildacdn.com/img/photo@2x.jpg":                                           "images/photo@2x.jpg",
+		"https://static.tildacdn.com/css/fonts-tildasans.css":                                    "css/fonts-tildasans.css",
+		"https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.js?t=1734259633": "js/tilda-blocks-page12345.min.js",
+		"https://static.tildacdn.com/img/cover.png":                                              "images/cover.png",
+	})
+
+	tests := []struct {
+		name         string
+		html         string
+		prefix       string
+		want         string
+		wantUnmapped []string
+	}{
+		{
+			name: "attributes",
+			html: `<!--allrecords--><div id="allrecords"><img src="https://static.tildacdn.com/img/photo.jpg" alt="Photo" srcset="https://static.tildacdn.com/img/photo.jpg 1x, https://static.tildacdn.com/img/photo@2x.jpg 2x"><script src="https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.js?t=1734259633" defer></script></div><!--/allrecords-->`,
+			want: `<!--allrecords--><div id="allrecords"><img src="images/photo.jpg" alt="Photo" srcset="images/photo.jpg 1x, images/photo@2x.jpg 2x"><script src="js/tilda-blocks-page12345.min.js" defer=""></script></div><!--/allrecords-->`,
+		}, {
+			name:   "lazy load and styles",
+			html:   `<head><link rel="stylesheet" href="//static.tildacdn.com/css/fonts-tildasans.css"><style>.t-cover{background-image:url('https://static.tildacdn.com/img/cover.png')}</style></head><div class="t-bgimg" data-original="https://static.tildacdn.com/img/photo.jpg" style="background-image: url(&quot;https://thumb.tildacdn.com/tild/-/resize/20x/photo.jpg&quot;);"></div><div style="background:url(http://static.tildacdn.com/img/cover.png) no-repeat"></div><div class="t-cover__carrier" data-content-cover-bg="https://static.tildacdn.com/img/cover.png"></div>`,
+			prefix: "../",
+			want:   `<head><link rel="stylesheet" href="../css/fonts-tildasans.css"><style>.t-cover{background-image:url('../images/cover.png')}</style></head><div class="t-bgimg" data-original="../images/photo.jpg" style="background-image: url(&#34;https://thumb.tildacdn.com/tild/-/resize/20x/photo.jpg&#34;);"></div><div style="background:url(../images/cover.png) no-repeat"></div><div class="t-cover__carrier" data-content-cover-bg="../images/cover.png"></div>`,
+			wantUnmapped: []string{
+				"https://thumb.tildacdn.com/tild/-/resize/20x/photo.jpg",
+			},
+		}, {
+			name: "query ignored",
+			html: `<a href="https://static.tildacdn.com/img/photo.jpg?v=2">Photo</a> <a href="https://tilda.cc/">Tilda</a> <a href="https://static.tildacdn.com/img/other.jpg">Other</a>`,
+			want: `<a href="images/photo.jpg">Photo</a> <a href="https://tilda.cc/">Tilda</a> <a href="https://static.tildacdn.com/img/other.jpg">Other</a>`,
+			wantUnmapped: []string{
+				"https://static.tildacdn.com/img/other.jpg",
+			},
+		}, {
+			name: "unchanged markup",
+			html: `<!DOCTYPE html><HTML><body CLASS='t-body'><p>Fish &amp; chips</p><br></body></HTML>`,
+			want: `<!DOCTYPE html><HTML><body CLASS='t-body'><p>Fish &amp; chips</p><br></body></HTML>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rewriter.Rewrite(tt.html, tt.prefix)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.HTML)
+			assert.Equal(t, tt.wantUnmapped, got.Unmapped)
+		})
+	}
+}
+
+func TestPageMapping(t *testing.T) {
+	got := PageMapping(tilda.PageExport{
+		ExportImgPath: "img",
+		Images:        []tilda.Image{{From: "https://static.tildacdn.com/img/tildacopy.png", To: "tildacopy.png"}},
+		JS:            []tilda.JS{{From: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js", To: "tilda-scripts-3.0.min.js"}},
+	})
+	assert.Equal(t, map[string]string{
+		"https://static.tildacdn.com/img/tildacopy.png":           "img/tildacopy.png",
+		"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js": "js/tilda-scripts-3.0.min.js",
+	}, got)
+}
+
+func TestRelativePrefix(t *testing.T) {
+	assert.Equal(t, "", RelativePrefix("page1.html"))
+	assert.Equal(t, "../", RelativePrefix("blog/index.html"))
+	assert.Equal(t, "../../", RelativePrefix("blog/post/index.html"))
+}
+
+func TestIsCDNURL(t *testing.T) {
+	assert.True(t, IsCDNURL("https://static.tildacdn.com/img/photo.jpg"))
+	assert.True(t, IsCDNURL("//thumb.tildacdn.info/img/photo.jpg"))
+	assert.False(t, IsCDNURL("https://nottildacdn.com/img/photo.jpg"))
+	assert.False(t, IsCDNURL("images/photo.jpg"))
+}
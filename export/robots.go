@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// RobotsFilename is the robots exclusion file written into the storage root
+const RobotsFilename = "robots.txt"
+
+// NoIndexContent is the content of robots meta tag added to pages hidden from search engines
+const NoIndexContent = "noindex, nofollow"
+
+// RobotsPolicy defines whether exported site can be indexed by search engines
+type RobotsPolicy int
+
+const (
+	RobotsProject RobotsPolicy = iota // Follow search setting of the project (NoSearch), robots meta tags of pages are kept
+	RobotsIndex                       // Allow indexing, noindex robots meta tags are removed from pages
+	RobotsNoIndex                     // Forbid indexing (e.g. staging mirror), robots meta tag is added to every page
+)
+
+// Modes of robots meta tags handling recorded in export state
+const (
+	robotsKeep    = ""
+	robotsIndex   = "index"
+	robotsNoIndex = "noindex"
+)
+
+// mode returns how robots meta tags of pages should be handled for the project
+func (p RobotsPolicy) mode(noSearch bool) string {
+	switch {
+	case p == RobotsNoIndex || (p == RobotsProject && noSearch):
+		return robotsNoIndex
+	case p == RobotsIndex:
+		return robotsIndex
+	}
+
+	return robotsKeep
+}
+
+// RobotsTxt returns robots.txt allowing or disallowing crawling of the whole site.
+// Sitemap is listed if sitemapURL is set and indexing is allowed.
+func RobotsTxt(noIndex bool, sitemapURL string) []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if noIndex {
+		b.WriteString("Disallow: /\n")
+		return []byte(b.String())
+	}
+
+	b.WriteString("Allow: /\n")
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+
+	return []byte(b.String())
+}
+
+// SetMetaRobots removes robots meta tags from HTML code and, if noIndex is set, adds robots meta tag
+// with NoIndexContent to the beginning of head element. If indexing is allowed, only tags containing
+// noindex or nofollow are removed. Other markup is kept as is.
+func SetMetaRobots(htmlCode string, noIndex bool) (string, error) {
+	var out bytes.Buffer
+	injected := !noIndex
+	tag := `<meta name="robots" content="` + NoIndexContent + `">`
+
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlCode))
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", fmt.Errorf("tokenize html: %w", err)
+			}
+
+			if !injected {
+				return tag + out.String(), nil
+			}
+
+			return out.String(), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			raw := string(tokenizer.Raw())
+			token := tokenizer.Token()
+
+			if token.Data == "meta" && strings.EqualFold(attrValue(token.Attr, "name"), "robots") {
+				content := strings.ToLower(attrValue(token.Attr, "content"))
+				if noIndex || strings.Contains(content, "noindex") || strings.Contains(content, "nofollow") {
+					continue
+				}
+			}
+
+			out.WriteString(raw)
+			if !injected && token.Data == "head" {
+				out.WriteString(tag)
+				injected = true
+			}
+		default:
+			out.Write(tokenizer.Raw())
+		}
+	}
+}
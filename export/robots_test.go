@@ -0,0 +1,120 @@
+package export
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestSetMetaRobots(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		noIndex bool
+		want    string
+	}{
+		{
+			name:    "inject",
+			html:    `<html><head lang="ru"><title>Page</title></head><body></body></html>`,
+			noIndex: true,
+			want:    `<html><head lang="ru"><meta name="robots" content="noindex, nofollow"><title>Page</title></head><body></body></html>`,
+		}, {
+			name:    "replace",
+			html:    `<html><head><meta name="robots" content="index, follow"/><title>Page</title></head></html>`,
+			noIndex: true,
+			want:    `<html><head><meta name="robots" content="noindex, nofollow"><title>Page</title></head></html>`,
+		}, {
+			name:    "no head",
+			html:    `<div id="allrecords"></div>`,
+			noIndex: true,
+			want:    `<meta name="robots" content="noindex, nofollow"><div id="allrecords"></div>`,
+		}, {
+			name: "strip",
+			html: `<html><head><META NAME="Robots" CONTENT="NOINDEX"><meta name="description" content="Page"></head></html>`,
+			want: `<html><head><meta name="description" content="Page"></head></html>`,
+		}, {
+			name: "keep allowing",
+			html: `<html><head><meta name="robots" content="max-image-preview:large"></head></html>`,
+			want: `<html><head><meta name="robots" content="max-image-preview:large"></head></html>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetMetaRobots(tt.html, tt.noIndex)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRobotsTxt(t *testing.T) {
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", string(RobotsTxt(true, "https://example.com/sitemap.xml")))
+	assert.Equal(t, "User-agent: *\nAllow: /\n", string(RobotsTxt(false, "")))
+	assert.Equal(t, "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n", string(RobotsTxt(false, "https://example.com/sitemap.xml")))
+}
+
+func TestProject_Robots(t *testing.T) {
+	tests := []struct {
+		name        string
+		noSearch    string
+		policy      RobotsPolicy
+		wantRobots  string
+		wantNoIndex bool
+	}{
+		{name: "project allows", policy: RobotsProject, wantRobots: "User-agent: *\nAllow: /\n"},
+		{name: "project forbids", noSearch: "y", policy: RobotsProject, wantRobots: "User-agent: *\nDisallow: /\n", wantNoIndex: true},
+		{name: "staging override", policy: RobotsNoIndex, wantRobots: "User-agent: *\nDisallow: /\n", wantNoIndex: true},
+		{name: "index override", noSearch: "y", policy: RobotsIndex, wantRobots: "User-agent: *\nAllow: /\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			registerTestProject(testPage("1", 10, ""))
+			registerAPI("getprojectexport", "projectid=54321", tilda.ProjectInfo{ID: "54321", IndexpageID: "1", NoSearch: tt.noSearch})
+
+			root := t.TempDir()
+			_, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithRobots(tt.policy))
+			assert.NoError(t, err)
+
+			bts, err := os.ReadFile(filepath.Join(root, RobotsFilename))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRobots, string(bts))
+
+			bts, err = os.ReadFile(filepath.Join(root, "index.html"))
+			assert.NoError(t, err)
+			if tt.wantNoIndex {
+				assert.Contains(t, string(bts), `<head><meta name="robots" content="noindex, nofollow">`)
+			} else {
+				assert.NotContains(t, string(bts), `name="robots"`)
+			}
+		})
+	}
+}
+
+func TestProject_RobotsChanged(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""))
+
+	root := t.TempDir()
+	_, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root), WithRobots(RobotsNoIndex))
+	assert.NoError(t, err)
+
+	result, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+	if assert.Len(t, result.Pages, 1) {
+		assert.False(t, result.Pages[0].Unchanged)
+	}
+
+	bts, err := os.ReadFile(filepath.Join(root, "page1.html"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(bts), `name="robots"`)
+}
@@ -0,0 +1,401 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm   = "AWS4-HMAC-SHA256"
+	s3DateFormat  = "20060102T150405Z"
+	s3ServiceName = "s3"
+)
+
+// S3Config configures S3Storage
+type S3Config struct {
+	Endpoint        string // Endpoint URL (e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000)
+	Region          string // Region used for signing requests (e.g. eu-central-1)
+	Bucket          string // Bucket name
+	Prefix          string // Prefix of object keys (e.g. sites/example/), files are written to the bucket root if empty
+	AccessKeyID     string // Access key ID
+	SecretAccessKey string // Secret access key
+	SessionToken    string // Session token of temporary credentials (optional)
+	VirtualHosted   bool   // Address bucket as subdomain of the endpoint host instead of the first path segment
+}
+
+// S3Error is the error returned by S3-compatible storage
+type S3Error struct {
+	StatusCode int    // HTTP status code
+	Code       string // Error code (e.g. AccessDenied)
+	Message    string // Error message
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("s3: status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// S3Storage stores files in the bucket of S3-compatible object storage. Requests are signed with AWS Signature
+// Version 4. Objects are replaced atomically by storage itself, so readers never see partially written content.
+type S3Storage struct {
+	config     S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// WithS3HttpClient option allows to use custom http client
+func WithS3HttpClient(httpClient *http.Client) func(*S3Storage) {
+	return func(s *S3Storage) {
+		s.httpClient = httpClient
+	}
+}
+
+// NewS3Storage creates storage writing files into the bucket
+func NewS3Storage(config S3Config, options ...func(*S3Storage)) (*S3Storage, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	s := &S3Storage{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Put uploads the file with a single request. Content type is detected by the file extension.
+func (s *S3Storage) Put(ctx context.Context, name string, r io.Reader) error {
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+
+	body, err := io.ReadAll(contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", contentType(name, body))
+	resp, err := s.do(ctx, http.MethodPut, s.config.Prefix+name, nil, header, body)
+	if err != nil {
+		return fmt.Errorf("put %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put %s: %w", name, s3ResponseError(resp))
+	}
+
+	return nil
+}
+
+// Get downloads the file
+func (s *S3Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, fmt.Errorf("invalid file name %q", name)
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, s.config.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("open %s: %w", name, fs.ErrNotExist)
+	default:
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("open %s: %w", name, s3ResponseError(resp))
+	}
+}
+
+// Delete removes the file
+func (s *S3Storage) Delete(ctx context.Context, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, s.config.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("remove %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("remove %s: %w", name, s3ResponseError(resp))
+	}
+}
+
+// s3ListResult is the response of ListObjectsV2 request
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+}
+
+// List lists objects with the prefix page by page
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.config.Prefix+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		result, err := s.list(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", prefix, err)
+		}
+
+		for _, object := range result.Contents {
+			names = append(names, strings.TrimPrefix(object.Key, s.config.Prefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *S3Storage) list(ctx context.Context, query url.Values) (*s3ListResult, error) {
+	resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3ResponseError(resp)
+	}
+
+	var result s3ListResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// do sends the signed request for the object key (the bucket itself if key is empty)
+func (s *S3Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	objectPath := strings.TrimSuffix(u.Path, "/") + "/"
+	if s.config.VirtualHosted {
+		u.Host = s.config.Bucket + "." + u.Host
+	} else {
+		objectPath += s.config.Bucket + "/"
+	}
+	objectPath += key
+	if key == "" && !s.config.VirtualHosted {
+		objectPath = strings.TrimSuffix(objectPath, "/")
+	}
+	u.Path = objectPath
+	u.RawPath = s3Escape(objectPath, false)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	payloadHash := sha256.Sum256(body)
+
+	signer := s3Signer{
+		accessKeyID:     s.config.AccessKeyID,
+		secretAccessKey: s.config.SecretAccessKey,
+		sessionToken:    s.config.SessionToken,
+		region:          s.config.Region,
+	}
+	signer.sign(req, hex.EncodeToString(payloadHash[:]), s.now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	return resp, nil
+}
+
+// s3ResponseError reads error of the failed response
+func s3ResponseError(resp *http.Response) error {
+	s3Err := &S3Error{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		s3Err.Code = body.Code
+		s3Err.Message = body.Message
+	}
+
+	return s3Err
+}
+
+// s3Signer signs requests with AWS Signature Version 4
+type s3Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	region          string
+}
+
+// sign sets date, payload hash and authorization headers of the request. Host and all headers set
+// before signing are signed.
+func (s s3Signer) sign(req *http.Request, payloadHash string, t time.Time) {
+	amzDate := t.UTC().Format(s3DateFormat)
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.Join(strings.Fields(strings.Join(v, ",")), " ")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + s3ServiceName + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := s3Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3ServiceName)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", s3Algorithm+" Credential="+s.accessKeyID+"/"+scope+
+		",SignedHeaders="+signedHeaders+",Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery returns query parameters sorted by name and encoded as required by Signature Version 4
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s3Escape(keys[i], true) < s3Escape(keys[j], true)
+	})
+
+	var params []string
+	for _, k := range keys {
+		values := make([]string, 0, len(query[k]))
+		for _, v := range query[k] {
+			values = append(values, s3Escape(v, true))
+		}
+		sort.Strings(values)
+
+		for _, v := range values {
+			params = append(params, s3Escape(k, true)+"="+v)
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// s3Escape percent-encodes everything except unreserved characters of RFC 3986 (and slashes unless encodeSlash)
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HtaccessFilename is the name of Apache config file placed into the site root
+const HtaccessFilename = ".htaccess"
+
+// FingerprintPattern matches names of fingerprinted assets (e.g. tilda-grid-3.0.min.0123abcd.css)
+// whose content never changes, so they can be cached forever
+const FingerprintPattern = `\.[0-9a-f]{8,}\.[A-Za-z0-9]+$`
+
+// ImmutableCacheControl is Cache-Control header value of fingerprinted assets
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+var fingerprintRegexp = regexp.MustCompile(FingerprintPattern)
+
+// ServerConfig represents routing rules of exported project used to generate web server configs
+type ServerConfig struct {
+	Rewrites  []ServerRoute // Alias paths served by page files
+	Redirects []ServerRoute // Old aliases permanently redirected to current page URLs
+	NotFound  string        // Path of 404 page (empty if the project has no 404 page)
+}
+
+// ServerRoute represents mapping of URL path to another path
+type ServerRoute struct {
+	From string // URL path without trailing slash (e.g. /blog)
+	To   string // Path of the file or URL path (e.g. /page2.html)
+}
+
+// IsFingerprinted reports whether the file name contains content hash (see FingerprintPattern)
+func IsFingerprinted(name string) bool {
+	return fingerprintRegexp.MatchString(name)
+}
+
+// NewServerConfig returns routing rules of the export described by the current manifest. Aliases pages had in
+// previous manifests are redirected to current URLs of these pages unless they are used by current pages.
+func NewServerConfig(current *Manifest, previous ...*Manifest) ServerConfig {
+	var config ServerConfig
+
+	pageURLs := make(map[string]string, len(current.Pages))
+	used := make(map[string]bool, len(current.Pages))
+	for _, page := range current.Pages {
+		pageURLs[page.ID] = pageURL("", current.Project.ProjectInfo(), page.ID, page.Alias, page.Path)
+		used["/"+page.Path] = true
+
+		if alias := aliasURLPath(page.Alias); alias != "" {
+			used[alias] = true
+			config.Rewrites = append(config.Rewrites, ServerRoute{From: alias, To: "/" + page.Path})
+		}
+
+		if isPageID(current.Project.Page404ID) && page.ID == current.Project.Page404ID {
+			config.NotFound = "/" + NotFoundFilename
+		}
+	}
+
+	redirects := make(map[string]string)
+	for _, manifest := range previous {
+		if manifest == nil {
+			continue
+		}
+
+		for _, page := range manifest.Pages {
+			from := aliasURLPath(page.Alias)
+			to, ok := pageURLs[page.ID]
+			if from == "" || !ok || used[from] || from == to {
+				continue
+			}
+
+			redirects[from] = to
+		}
+	}
+
+	for from, to := range redirects {
+		config.Redirects = append(config.Redirects, ServerRoute{From: from, To: to})
+	}
+	sort.Slice(config.Redirects, func(i, j int) bool {
+		return config.Redirects[i].From < config.Redirects[j].From
+	})
+
+	return config
+}
+
+// Nginx renders directives to include into server block of nginx config
+func (c ServerConfig) Nginx() string {
+	var b strings.Builder
+	if c.NotFound != "" {
+		fmt.Fprintf(&b, "error_page 404 %s;\n\n", c.NotFound)
+	}
+
+	for _, route := range c.Redirects {
+		fmt.Fprintf(&b, "rewrite \"^%s/?$\" %s permanent;\n", regexp.QuoteMeta(route.From), route.To)
+	}
+	for _, route := range c.Rewrites {
+		fmt.Fprintf(&b, "rewrite \"^%s/?$\" %s last;\n", regexp.QuoteMeta(route.From), route.To)
+	}
+	if len(c.Redirects)+len(c.Rewrites) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "location ~* \"%s\" {\n", FingerprintPattern)
+	fmt.Fprintf(&b, "    add_header Cache-Control \"%s\";\n", ImmutableCacheControl)
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Htaccess renders Apache .htaccess file placed into the site root
+func (c ServerConfig) Htaccess() string {
+	var b strings.Builder
+	if c.NotFound != "" {
+		fmt.Fprintf(&b, "ErrorDocument 404 %s\n\n", c.NotFound)
+	}
+
+	if len(c.Redirects)+len(c.Rewrites) > 0 {
+		b.WriteString("RewriteEngine On\n")
+		for _, route := range c.Redirects {
+			fmt.Fprintf(&b, "RewriteRule ^%s/?$ %s [R=301,L]\n", regexp.QuoteMeta(strings.TrimPrefix(route.From, "/")), route.To)
+		}
+		for _, route := range c.Rewrites {
+			fmt.Fprintf(&b, "RewriteRule ^%s/?$ %s [L]\n", regexp.QuoteMeta(strings.TrimPrefix(route.From, "/")), route.To)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("<IfModule mod_headers.c>\n")
+	fmt.Fprintf(&b, "    <FilesMatch \"%s\">\n", FingerprintPattern)
+	fmt.Fprintf(&b, "        Header set Cache-Control \"%s\"\n", ImmutableCacheControl)
+	b.WriteString("    </FilesMatch>\n")
+	b.WriteString("</IfModule>\n")
+
+	return b.String()
+}
+
+// Caddy renders directives to include into site block of Caddyfile
+func (c ServerConfig) Caddy() string {
+	var b strings.Builder
+	for _, route := range c.Redirects {
+		fmt.Fprintf(&b, "redir %s %s permanent\n", route.From, route.To)
+		fmt.Fprintf(&b, "redir %s/ %s permanent\n", route.From, route.To)
+	}
+	for _, route := range c.Rewrites {
+		fmt.Fprintf(&b, "rewrite %s %s\n", route.From, route.To)
+		fmt.Fprintf(&b, "rewrite %s/ %s\n", route.From, route.To)
+	}
+	if len(c.Redirects)+len(c.Rewrites) > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "@fingerprinted path_regexp %s\n", FingerprintPattern)
+	fmt.Fprintf(&b, "header @fingerprinted Cache-Control \"%s\"\n", ImmutableCacheControl)
+	b.WriteString("\nfile_server\n")
+
+	if c.NotFound != "" {
+		b.WriteString("\nhandle_errors {\n")
+		b.WriteString("    @notfound expression {err.status_code} == 404\n")
+		fmt.Fprintf(&b, "    rewrite @notfound %s\n", c.NotFound)
+		b.WriteString("    file_server\n")
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
+
+// aliasURLPath returns URL path of the alias (e.g. /blog) or empty string if the alias is not set or not valid
+func aliasURLPath(alias string) string {
+	if _, ok := AliasPath(alias); !ok {
+		return ""
+	}
+
+	return "/" + strings.Trim(alias, "/")
+}
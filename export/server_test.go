@@ -0,0 +1,143 @@
+package export
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testServerConfig() ServerConfig {
+	project := ManifestProject{ID: "54321", IndexpageID: "1", Page404ID: "3"}
+	previous := &Manifest{
+		Version: ManifestVersion,
+		Project: project,
+		Pages: []PageResult{
+			{ID: "1", Alias: "home", Path: "page1.html"},
+			{ID: "2", Alias: "news", Path: "page2.html"},
+			{ID: "3", Alias: "not-found", Path: "page3.html"},
+			{ID: "4", Alias: "old", Path: "page4.html"},
+			{ID: "5", Alias: "about", Path: "page5.html"},
+		},
+	}
+	older := &Manifest{
+		Version: ManifestVersion,
+		Project: project,
+		Pages: []PageResult{
+			{ID: "2", Alias: "blog-old", Path: "page2.html"},
+			{ID: "5", Alias: "blog", Path: "page5.html"},
+		},
+	}
+	current := &Manifest{
+		Version: ManifestVersion,
+		Project: project,
+		Pages: []PageResult{
+			{ID: "1", Path: "page1.html"},
+			{ID: "2", Alias: "blog", Path: "page2.html"},
+			{ID: "3", Alias: "not-found", Path: "page3.html"},
+			{ID: "5", Path: "page5.html"},
+		},
+	}
+
+	return NewServerConfig(current, previous, nil, older)
+}
+
+func TestNewServerConfig(t *testing.T) {
+	assert.Equal(t, ServerConfig{
+		Rewrites: []ServerRoute{
+			{From: "/blog", To: "/page2.html"},
+			{From: "/not-found", To: "/page3.html"},
+		},
+		Redirects: []ServerRoute{
+			{From: "/about", To: "/page5.html"},
+			{From: "/blog-old", To: "/blog"},
+			{From: "/home", To: "/"},
+			{From: "/news", To: "/blog"},
+		},
+		NotFound: "/404.html",
+	}, testServerConfig())
+}
+
+func TestServerConfig_Nginx(t *testing.T) {
+	assert.Equal(t, `error_page 404 /404.html;
+
+rewrite "^/about/?$" /page5.html permanent;
+rewrite "^/blog-old/?$" /blog permanent;
+rewrite "^/home/?$" / permanent;
+rewrite "^/news/?$" /blog permanent;
+rewrite "^/blog/?$" /page2.html last;
+rewrite "^/not-found/?$" /page3.html last;
+
+location ~* "\.[0-9a-f]{8,}\.[A-Za-z0-9]+$" {
+    add_header Cache-Control "public, max-age=31536000, immutable";
+}
+`, testServerConfig().Nginx())
+}
+
+func TestServerConfig_Htaccess(t *testing.T) {
+	assert.Equal(t, `ErrorDocument 404 /404.html
+
+RewriteEngine On
+RewriteRule ^about/?$ /page5.html [R=301,L]
+RewriteRule ^blog-old/?$ /blog [R=301,L]
+RewriteRule ^home/?$ / [R=301,L]
+RewriteRule ^news/?$ /blog [R=301,L]
+RewriteRule ^blog/?$ /page2.html [L]
+RewriteRule ^not-found/?$ /page3.html [L]
+
+<IfModule mod_headers.c>
+    <FilesMatch "\.[0-9a-f]{8,}\.[A-Za-z0-9]+$">
+        Header set Cache-Control "public, max-age=31536000, immutable"
+    </FilesMatch>
+</IfModule>
+`, testServerConfig().Htaccess())
+
+	assert.Equal(t, `<IfModule mod_headers.c>
+    <FilesMatch "\.[0-9a-f]{8,}\.[A-Za-z0-9]+$">
+        Header set Cache-Control "public, max-age=31536000, immutable"
+    </FilesMatch>
+</IfModule>
+`, ServerConfig{}.Htaccess())
+}
+
+func TestServerConfig_Caddy(t *testing.T) {
+	assert.Equal(t, `redir /about /page5.html permanent
+redir /about/ /page5.html permanent
+redir /blog-old /blog permanent
+redir /blog-old/ /blog permanent
+redir /home / permanent
+redir /home/ / permanent
+redir /news /blog permanent
+redir /news/ /blog permanent
+rewrite /blog /page2.html
+rewrite /blog/ /page2.html
+rewrite /not-found /page3.html
+rewrite /not-found/ /page3.html
+
+@fingerprinted path_regexp \.[0-9a-f]{8,}\.[A-Za-z0-9]+$
+header @fingerprinted Cache-Control "public, max-age=31536000, immutable"
+
+file_server
+
+handle_errors {
+    @notfound expression {err.status_code} == 404
+    rewrite @notfound /404.html
+    file_server
+}
+`, testServerConfig().Caddy())
+}
+
+func TestIsFingerprinted(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "css/tilda-grid-3.0.min.0123abcd.css", want: true},
+		{name: "images/photo.0123456789abcdef.JPG", want: true},
+		{name: "css/tilda-grid-3.0.min.css"},
+		{name: "js/tilda-scripts-3.0.min.js"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsFingerprinted(tt.name))
+		})
+	}
+}
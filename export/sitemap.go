@@ -0,0 +1,210 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+// SitemapFilename is the sitemap (or sitemap index if there are too many pages) written into the storage root
+const SitemapFilename = "sitemap.xml"
+
+// MaxSitemapURLs is the max number of URLs in single sitemap file allowed by sitemaps protocol
+const MaxSitemapURLs = 50000
+
+// ErrNoBaseURL is returned when sitemap can't be generated because the project has no domain and no base URL is set
+var ErrNoBaseURL = errors.New("project has no domain and base URL is not set")
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapOptions allows to customize sitemap generation
+type SitemapOptions struct {
+	BaseURL string // URL of the site (e.g. self-hosted mirror), project domain is used if not set
+	MaxURLs int    // Max number of URLs in single sitemap file (MaxSitemapURLs if not set)
+}
+
+// SitemapFile represents generated sitemap file
+type SitemapFile struct {
+	Name string // Path of the file in the storage
+	Data []byte // XML content
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// Sitemap generates sitemap of the project pages with canonical URLs sorted by Sort. 404, header and footer pages
+// are left out. If there are more URLs than fit into single sitemap, pages are split into sitemap-N.xml files
+// listed in sitemap index written as SitemapFilename. No files are returned for projects hidden from search engines.
+func Sitemap(project tilda.ProjectInfo, pages []tilda.Page, opts SitemapOptions) ([]SitemapFile, error) {
+	if NoSearch(project) {
+		return nil, nil
+	}
+
+	baseURL := strings.TrimSuffix(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = projectBaseURL(project)
+	}
+	if baseURL == "" {
+		return nil, ErrNoBaseURL
+	}
+
+	maxURLs := opts.MaxURLs
+	if maxURLs < 1 || maxURLs > MaxSitemapURLs {
+		maxURLs = MaxSitemapURLs
+	}
+
+	sorted := make([]tilda.Page, len(pages))
+	copy(sorted, pages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Sort < sorted[j].Sort
+	})
+
+	type entry struct {
+		url     sitemapURL
+		lastMod time.Time
+	}
+
+	var entries []entry
+	for _, page := range sorted {
+		if !IsSitemapPage(project, page.ID) {
+			continue
+		}
+
+		lastMod := pageLastMod(page)
+		e := entry{url: sitemapURL{Loc: pageURL(baseURL, project, page.ID, page.Alias, page.Filename)}, lastMod: lastMod}
+		if !lastMod.IsZero() {
+			e.url.LastMod = lastMod.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) <= maxURLs {
+		set := sitemapURLSet{XMLNS: sitemapXMLNS, URLs: make([]sitemapURL, 0, len(entries))}
+		for _, e := range entries {
+			set.URLs = append(set.URLs, e.url)
+		}
+
+		data, err := marshalSitemap(set)
+		if err != nil {
+			return nil, err
+		}
+
+		return []SitemapFile{{Name: SitemapFilename, Data: data}}, nil
+	}
+
+	index := sitemapIndex{XMLNS: sitemapXMLNS}
+	var files []SitemapFile
+	for start := 0; start < len(entries); start += maxURLs {
+		chunk := entries[start:min(start+maxURLs, len(entries))]
+
+		set := sitemapURLSet{XMLNS: sitemapXMLNS, URLs: make([]sitemapURL, 0, len(chunk))}
+		var latest time.Time
+		for _, e := range chunk {
+			set.URLs = append(set.URLs, e.url)
+			if e.lastMod.After(latest) {
+				latest = e.lastMod
+			}
+		}
+
+		data, err := marshalSitemap(set)
+		if err != nil {
+			return nil, err
+		}
+
+		name := "sitemap-" + strconv.Itoa(len(files)+1) + ".xml"
+		files = append(files, SitemapFile{Name: name, Data: data})
+
+		ref := sitemapURL{Loc: baseURL + "/" + name}
+		if !latest.IsZero() {
+			ref.LastMod = latest.UTC().Format(time.RFC3339)
+		}
+		index.Sitemaps = append(index.Sitemaps, ref)
+	}
+
+	data, err := marshalSitemap(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]SitemapFile{{Name: SitemapFilename, Data: data}}, files...), nil
+}
+
+// WriteSitemap generates sitemap of the project pages and writes its files into the storage
+func WriteSitemap(ctx context.Context, storage Storage, project tilda.ProjectInfo, pages []tilda.Page, opts SitemapOptions) ([]string, error) {
+	files, err := Sitemap(project, pages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if err := storage.Put(ctx, file.Name, bytes.NewReader(file.Data)); err != nil {
+			return names, fmt.Errorf("put %s: %w", file.Name, err)
+		}
+
+		names = append(names, file.Name)
+	}
+
+	return names, nil
+}
+
+// IsSitemapPage reports whether the page should be listed in sitemap (it's not 404, header or footer page)
+func IsSitemapPage(project tilda.ProjectInfo, pageID string) bool {
+	for _, special := range []string{project.Page404ID, project.HeaderpageID, project.FooterpageID} {
+		if isPageID(special) && pageID == special {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NoSearch reports whether the project is hidden from search engines in its settings
+func NoSearch(project tilda.ProjectInfo) bool {
+	switch strings.ToLower(strings.TrimSpace(project.NoSearch)) {
+	case "", "0", "n", "no", "false":
+		return false
+	}
+
+	return true
+}
+
+// pageLastMod returns time of the last page publication or page date if the page was not published
+func pageLastMod(page tilda.Page) time.Time {
+	if page.Published > 0 {
+		return time.Unix(int64(page.Published), 0)
+	}
+
+	return time.Time(page.Date)
+}
+
+func marshalSitemap(v any) ([]byte, error) {
+	bts, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal sitemap: %w", err)
+	}
+
+	return append([]byte(xml.Header), append(bts, '\n')...), nil
+}
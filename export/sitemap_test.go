@@ -0,0 +1,161 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tilda "github.com/dimuska139/tilda-go"
+)
+
+func TestSitemap(t *testing.T) {
+	project := tilda.ProjectInfo{
+		ID:           "54321",
+		CustomDomain: "example.com",
+		IndexpageID:  "1",
+		HeaderpageID: "4",
+		FooterpageID: "5",
+		Page404ID:    "3",
+	}
+	pages := []tilda.Page{
+		{ID: "2", Alias: "blog", Sort: 20, Date: tilda.DateTime(time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC))},
+		{ID: "1", Sort: 10, Published: 1734259400},
+		{ID: "3", Alias: "not-found", Sort: 30, Published: 1734259400},
+		{ID: "4", Sort: 40, Published: 1734259400},
+		{ID: "5", Sort: 50, Published: 1734259400},
+		{ID: "6", Sort: 60, Filename: "page6.html"},
+	}
+
+	tests := []struct {
+		name    string
+		project tilda.ProjectInfo
+		opts    SitemapOptions
+		want    []SitemapFile
+		wantErr error
+	}{
+		{
+			name:    "project domain",
+			project: project,
+			want: []SitemapFile{{Name: "sitemap.xml", Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2024-12-15T10:43:20Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/blog</loc>
+    <lastmod>2024-12-15T13:20:30Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/page6.html</loc>
+  </url>
+</urlset>
+`)}},
+		}, {
+			name:    "sitemap index",
+			project: project,
+			opts:    SitemapOptions{BaseURL: "https://mirror.example.com/", MaxURLs: 2},
+			want: []SitemapFile{
+				{Name: "sitemap.xml", Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://mirror.example.com/sitemap-1.xml</loc>
+    <lastmod>2024-12-15T13:20:30Z</lastmod>
+  </sitemap>
+  <sitemap>
+    <loc>https://mirror.example.com/sitemap-2.xml</loc>
+  </sitemap>
+</sitemapindex>
+`)},
+				{Name: "sitemap-1.xml", Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://mirror.example.com/</loc>
+    <lastmod>2024-12-15T10:43:20Z</lastmod>
+  </url>
+  <url>
+    <loc>https://mirror.example.com/blog</loc>
+    <lastmod>2024-12-15T13:20:30Z</lastmod>
+  </url>
+</urlset>
+`)},
+				{Name: "sitemap-2.xml", Data: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://mirror.example.com/page6.html</loc>
+  </url>
+</urlset>
+`)},
+			},
+		}, {
+			name:    "no search",
+			project: tilda.ProjectInfo{CustomDomain: "example.com", NoSearch: "y"},
+		}, {
+			name:    "no domain",
+			project: tilda.ProjectInfo{ID: "54321"},
+			wantErr: ErrNoBaseURL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Sitemap(tt.project, pages, tt.opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSitemap_MaxURLs(t *testing.T) {
+	pages := make([]tilda.Page, 0, MaxSitemapURLs+1)
+	for i := range MaxSitemapURLs + 1 {
+		pages = append(pages, tilda.Page{ID: fmt.Sprint(i + 1), Sort: i})
+	}
+
+	files, err := Sitemap(tilda.ProjectInfo{CustomDomain: "example.com"}, pages, SitemapOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, files, 3) {
+		assert.Equal(t, "sitemap.xml", files[0].Name)
+		assert.Equal(t, "sitemap-1.xml", files[1].Name)
+		assert.Equal(t, "sitemap-2.xml", files[2].Name)
+		assert.Contains(t, string(files[2].Data), "<loc>https://example.com/page50001.html</loc>")
+	}
+}
+
+func TestWriteSitemap(t *testing.T) {
+	root := t.TempDir()
+	names, err := WriteSitemap(context.Background(), NewDirStorage(root),
+		tilda.ProjectInfo{CustomDomain: "example.com"}, []tilda.Page{{ID: "1", Alias: "about"}}, SitemapOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{SitemapFilename}, names)
+
+	bts, err := os.ReadFile(filepath.Join(root, SitemapFilename))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bts), "<loc>https://example.com/about</loc>")
+}
+
+func TestNoSearch(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "", want: false},
+		{value: "0", want: false},
+		{value: "n", want: false},
+		{value: "y", want: true},
+		{value: "1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, NoSearch(tilda.ProjectInfo{NoSearch: tt.value}))
+		})
+	}
+}
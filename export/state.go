@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// StateFilename is the file in the storage where Exporter keeps the state of the previous export
+const StateFilename = ".tilda-export.json"
+
+// exportState represents pages and assets written by the previous export
+type exportState struct {
+	Pages         map[string]pageState      `json:"pages"`                  // Exported pages by page ID
+	ProjectAssets map[string]string         `json:"project_assets"`         // Checksums of project assets by path
+	Robots        string                    `json:"robots,omitempty"`       // Mode of robots meta tags handling in exported pages
+	Fingerprints  bool                      `json:"fingerprints,omitempty"` // Assets were written under fingerprinted paths
+	Integrity     bool                      `json:"integrity,omitempty"`    // Integrity attributes were added to pages
+	Images        string                    `json:"images,omitempty"`       // Options of image optimization
+	Downloads     map[string]DownloadResult `json:"downloads,omitempty"`    // Downloaded assets with response validators by asset path
+}
+
+// pageState represents exported page
+type pageState struct {
+	Published int               `json:"published"` // Published value of exported page (0 if some page assets were not downloaded)
+	Result    PageResult        `json:"result"`
+	Assets    map[string]string `json:"assets"` // Checksums of page assets by path (empty if asset was not downloaded)
+}
+
+func newExportState() *exportState {
+	return &exportState{
+		Pages:         make(map[string]pageState),
+		ProjectAssets: make(map[string]string),
+		Downloads:     make(map[string]DownloadResult),
+	}
+}
+
+// loadState reads the state of the previous export or returns empty state if the project was not exported yet
+func loadState(ctx context.Context, storage Storage) (*exportState, error) {
+	rc, err := storage.Get(ctx, StateFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return newExportState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	state := newExportState()
+	if err := json.NewDecoder(rc).Decode(state); err != nil {
+		return nil, fmt.Errorf("unmarshal state: %w", err)
+	}
+
+	return state, nil
+}
+
+// save writes the state into the storage
+func (s *exportState) save(ctx context.Context, storage Storage) error {
+	bts, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+
+	return storage.Put(ctx, StateFilename, bytes.NewReader(bts))
+}
+
+// downloads returns download results of the previous export that can be revalidated with the same options
+func (s *exportState) downloads(fingerprint bool, images string) []DownloadResult {
+	if s.Fingerprints != fingerprint || s.Images != images {
+		return nil
+	}
+
+	results := make([]DownloadResult, 0, len(s.Downloads))
+	for _, result := range s.Downloads {
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// files returns set of page outputs and asset paths listed in the state
+func (s *exportState) files() map[string]bool {
+	files := make(map[string]bool)
+	for name := range s.ProjectAssets {
+		files[name] = true
+	}
+	for _, page := range s.Pages {
+		files[page.Result.Path] = true
+		for _, name := range page.Result.Copies {
+			files[name] = true
+		}
+		for name := range page.Assets {
+			files[name] = true
+		}
+	}
+
+	return files
+}
+
+// removedFiles returns sorted files of the previous state that are not listed in the next state
+func removedFiles(prev, next *exportState) []string {
+	kept := next.files()
+
+	var removed []string
+	for name := range prev.files() {
+		if !kept[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	return removed
+}
@@ -0,0 +1,245 @@
+// Package export contains tools for exporting Tilda projects into static sites
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage is the destination of exported files. Names are slash-separated paths relative to the storage root.
+type Storage interface {
+	// Put writes the file atomically, so readers never see partially written content
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get opens the file, the error wraps fs.ErrNotExist if there is no such file
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete removes the file, deleting missing file is not an error
+	Delete(ctx context.Context, name string) error
+	// List returns sorted names of files starting with the prefix (e.g. "images/"), all files if prefix is empty
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// checksumStorage is implemented by storages knowing checksums of files without reading them (e.g. Archive)
+type checksumStorage interface {
+	// Checksum returns hex-encoded SHA-256 checksum of the file
+	Checksum(ctx context.Context, name string) (string, error)
+}
+
+// DirStorage stores files in the local directory
+type DirStorage struct {
+	root string
+}
+
+// NewDirStorage creates storage writing files into root directory
+func NewDirStorage(root string) *DirStorage {
+	return &DirStorage{root: root}
+}
+
+// Put writes the file into temporary file first and renames it after all content is written
+func (s *DirStorage) Put(ctx context.Context, name string, r io.Reader) error {
+	fullPath, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), "."+filepath.Base(fullPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod %s: %w", name, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", name, err)
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return fmt.Errorf("rename %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Get opens the file for reading
+func (s *DirStorage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	fullPath, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+
+	return f, nil
+}
+
+// Delete removes the file
+func (s *DirStorage) Delete(_ context.Context, name string) error {
+	fullPath, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// List walks the directory containing prefix. Temporary files of unfinished writes are skipped.
+func (s *DirStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	dir := "."
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = strings.TrimSuffix(prefix[:i], "/")
+	}
+	if !fs.ValidPath(dir) {
+		return nil, fmt.Errorf("invalid prefix %q", prefix)
+	}
+
+	var names []string
+	err := fs.WalkDir(os.DirFS(s.root), dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasPrefix(name, prefix) || isTempFile(d.Name()) {
+			return nil
+		}
+
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", prefix, err)
+	}
+	// Walk order differs from sorted order if directory name is prefix of file name (e.g. a/b.txt and a-b.txt)
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// isTempFile reports whether the file is the temporary file created by DirStorage.Put
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".tmp")
+}
+
+func (s *DirStorage) path(name string) (string, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean(name))), nil
+}
+
+// MemoryStorage keeps files in memory. It is useful for tests and for exports post-processed before writing.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryStorage creates empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string][]byte)}
+}
+
+// Put reads the whole content before replacing the file
+func (s *MemoryStorage) Put(ctx context.Context, name string, r io.Reader) error {
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+
+	data, err := io.ReadAll(contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[name] = data
+
+	return nil
+}
+
+// Get opens the file for reading
+func (s *MemoryStorage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.files[name]
+	if !ok {
+		return nil, fmt.Errorf("open %s: %w", name, fs.ErrNotExist)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete removes the file
+func (s *MemoryStorage) Delete(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.files, name)
+
+	return nil
+}
+
+// List returns sorted names of files starting with the prefix
+func (s *MemoryStorage) List(_ context.Context, prefix string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for name := range s.files {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// contextReader stops reading when context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirStorage(t *testing.T) {
+	root := t.TempDir()
+	storage := NewDirStorage(root)
+	ctx := context.Background()
+
+	assert.NoError(t, storage.Put(ctx, "css/fonts-tildasans.css", strings.NewReader("body{}")))
+	assert.NoError(t, storage.Put(ctx, "css/fonts-tildasans.css", strings.NewReader("body{margin:0}")))
+
+	bts, err := os.ReadFile(filepath.Join(root, "css", "fonts-tildasans.css"))
+	assert.NoError(t, err)
+	assert.Equal(t, "body{margin:0}", string(bts))
+
+	entries, err := os.ReadDir(filepath.Join(root, "css"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	rc, err := storage.Get(ctx, "css/fonts-tildasans.css")
+	assert.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Close())
+	assert.Equal(t, "body{margin:0}", string(got))
+
+	_, err = storage.Get(ctx, "css/missing.css")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.NoError(t, storage.Delete(ctx, "css/fonts-tildasans.css"))
+	assert.NoError(t, storage.Delete(ctx, "css/fonts-tildasans.css"))
+	_, err = storage.Get(ctx, "css/fonts-tildasans.css")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestDirStorage_InvalidName(t *testing.T) {
+	storage := NewDirStorage(t.TempDir())
+
+	tests := []string{"", ".", "../outside.html", "/etc/passwd", "a/../../b"}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, storage.Put(context.Background(), name, strings.NewReader("")))
+
+			_, err := storage.Get(context.Background(), name)
+			assert.Error(t, err)
+
+			assert.Error(t, storage.Delete(context.Background(), name))
+		})
+	}
+}
+
+func TestDirStorage_Canceled(t *testing.T) {
+	root := t.TempDir()
+	storage := NewDirStorage(root)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, storage.Put(ctx, "index.html", strings.NewReader("<html></html>")), context.Canceled)
+
+	_, err := os.Stat(filepath.Join(root, "index.html"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestStorage_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage Storage
+	}{
+		{name: "dir", storage: NewDirStorage(filepath.Join(t.TempDir(), "site"))},
+		{name: "memory", storage: NewMemoryStorage()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			names, err := tt.storage.List(ctx, "")
+			assert.NoError(t, err)
+			assert.Empty(t, names)
+
+			for _, name := range []string{"index.html", "blog/index.html", "images/photo1.jpg", "images/photo2.jpg", "images2/photo.jpg"} {
+				assert.NoError(t, tt.storage.Put(ctx, name, strings.NewReader(name)))
+			}
+
+			names, err = tt.storage.List(ctx, "")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"blog/index.html", "images/photo1.jpg", "images/photo2.jpg", "images2/photo.jpg", "index.html"}, names)
+
+			names, err = tt.storage.List(ctx, "images/")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"images/photo1.jpg", "images/photo2.jpg"}, names)
+
+			names, err = tt.storage.List(ctx, "images")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"images/photo1.jpg", "images/photo2.jpg", "images2/photo.jpg"}, names)
+
+			// Directory name is prefix of file names sorted before the directory content
+			for _, name := range []string{"a.txt", "a/b.txt", "a-b.txt"} {
+				assert.NoError(t, tt.storage.Put(ctx, name, strings.NewReader(name)))
+			}
+			names, err = tt.storage.List(ctx, "a")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a-b.txt", "a.txt", "a/b.txt"}, names)
+
+			names, err = tt.storage.List(ctx, "missing/")
+			assert.NoError(t, err)
+			assert.Empty(t, names)
+
+			assert.NoError(t, tt.storage.Delete(ctx, "images/photo1.jpg"))
+			names, err = tt.storage.List(ctx, "images/")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"images/photo2.jpg"}, names)
+
+			rc, err := tt.storage.Get(ctx, "images/photo2.jpg")
+			assert.NoError(t, err)
+			got, err := io.ReadAll(rc)
+			assert.NoError(t, err)
+			assert.NoError(t, rc.Close())
+			assert.Equal(t, "images/photo2.jpg", string(got))
+
+			_, err = tt.storage.Get(ctx, "images/photo1.jpg")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+			assert.Error(t, tt.storage.Put(ctx, "../outside.html", strings.NewReader("")))
+		})
+	}
+}
+
+func TestDirStorage_ListSkipsTemporaryFiles(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, ".index.html.123.tmp"), []byte("<html>"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("<html></html>"), 0o644))
+
+	names, err := NewDirStorage(root).List(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"index.html"}, names)
+}
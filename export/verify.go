@@ -0,0 +1,305 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrVerificationFailed is returned by VerifyReport.Err when the export has issues
+var ErrVerificationFailed = errors.New("export verification failed")
+
+// VerifyIssueKind is the kind of problem found in the export
+type VerifyIssueKind string
+
+const (
+	IssueMissingPage      VerifyIssueKind = "missing_page"      // Output file of the page listed in the manifest doesn't exist
+	IssueMissingAsset     VerifyIssueKind = "missing_asset"     // Referenced local asset doesn't exist
+	IssueChecksumMismatch VerifyIssueKind = "checksum_mismatch" // Referenced local asset doesn't match its manifest checksum
+	IssueCDNReference     VerifyIssueKind = "cdn_reference"     // Resource is loaded from Tilda CDN by HTML or CSS file
+)
+
+// VerifyReport represents result of export verification
+type VerifyReport struct {
+	Files  int           // Number of checked HTML and CSS files
+	Assets int           // Number of checked local assets
+	Issues []VerifyIssue // Issues sorted by file and reference
+}
+
+// VerifyIssue represents a problem found in the export
+type VerifyIssue struct {
+	Kind VerifyIssueKind
+	File string // File containing the reference or output file of the page
+	Ref  string // Reference as written in the file (empty for missing pages)
+}
+
+// String converts issue to human-readable form
+func (i VerifyIssue) String() string {
+	if i.Ref == "" {
+		return fmt.Sprintf("%s: %s", i.File, i.Kind)
+	}
+
+	return fmt.Sprintf("%s: %s %s", i.File, i.Kind, i.Ref)
+}
+
+// OK reports whether no issues were found
+func (r *VerifyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns error wrapping ErrVerificationFailed if issues were found, so the report can be used in deploy gates
+func (r *VerifyReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+
+	messages := make([]string, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		messages = append(messages, issue.String())
+	}
+
+	return fmt.Errorf("%w: %d issues: %s", ErrVerificationFailed, len(r.Issues), strings.Join(messages, "; "))
+}
+
+// Verify checks the export in the local directory (see VerifyFS)
+func Verify(dir string) (*VerifyReport, error) {
+	return VerifyFS(os.DirFS(dir))
+}
+
+// VerifyFS checks that every page listed in the manifest has output files, every local asset referenced
+// in HTML and CSS files exists and matches its manifest checksum, and no resources are loaded from Tilda CDN
+// (links and resource hints such as dns-prefetch are allowed).
+// The error is returned only if the export can't be checked (e.g. there is no manifest), problems are listed in the report.
+func VerifyFS(fsys fs.FS) (*VerifyReport, error) {
+	f, err := fsys.Open(ManifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	manifest, err := ReadManifest(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{}
+	for _, page := range manifest.Pages {
+		for _, name := range append([]string{page.Path}, page.Copies...) {
+			if _, err := fs.Stat(fsys, name); err != nil {
+				report.Issues = append(report.Issues, VerifyIssue{Kind: IssueMissingPage, File: name})
+			}
+		}
+	}
+
+	var names []string
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isVerifiedFile(name) {
+			names = append(names, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk export: %w", err)
+	}
+
+	checked := make(map[string]VerifyIssueKind)
+	for _, name := range names {
+		refs, err := fileRefs(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+
+		report.Files++
+		for _, ref := range refs {
+			if !ref.asset {
+				continue
+			}
+
+			if IsCDNURL(ref.value) {
+				report.Issues = append(report.Issues, VerifyIssue{Kind: IssueCDNReference, File: name, Ref: ref.value})
+				continue
+			}
+
+			target, ok := localRef(name, ref.value)
+			if !ok {
+				continue
+			}
+
+			kind, seen := checked[target]
+			if !seen {
+				kind = checkAsset(fsys, manifest, target)
+				checked[target] = kind
+			}
+			if kind != "" {
+				report.Issues = append(report.Issues, VerifyIssue{Kind: kind, File: name, Ref: ref.value})
+			}
+		}
+	}
+	report.Assets = len(checked)
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		if report.Issues[i].File != report.Issues[j].File {
+			return report.Issues[i].File < report.Issues[j].File
+		}
+
+		return report.Issues[i].Ref < report.Issues[j].Ref
+	})
+
+	return report, nil
+}
+
+// fileRef represents URL referenced in HTML or CSS file
+type fileRef struct {
+	value string
+	asset bool // The URL is loaded by the page (not a link to other page or resource hint)
+}
+
+// assetLinkRels are rel values of link elements loading resources. Other link elements are links to pages
+// (canonical, alternate) or resource hints (dns-prefetch, preconnect, preload) Tilda leaves in exported pages.
+var assetLinkRels = map[string]bool{
+	"stylesheet":       true,
+	"icon":             true,
+	"apple-touch-icon": true,
+	"mask-icon":        true,
+	"manifest":         true,
+}
+
+// loadsResource reports whether href attribute of the element references resource loaded by the page
+func loadsResource(token html.Token) bool {
+	if token.Data != "link" {
+		// Links of a and area elements lead to pages, not assets
+		return false
+	}
+
+	for _, rel := range strings.Fields(strings.ToLower(attrValue(token.Attr, "rel"))) {
+		if assetLinkRels[rel] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isVerifiedFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm", ".css":
+		return true
+	}
+
+	return false
+}
+
+// fileRefs returns URLs referenced in HTML file attributes and styles or in url() expressions of CSS file
+func fileRefs(fsys fs.FS, name string) ([]fileRef, error) {
+	bts, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(path.Ext(name), ".css") {
+		return cssRefs(string(bts)), nil
+	}
+
+	var refs []fileRef
+	tokenizer := html.NewTokenizer(strings.NewReader(string(bts)))
+	var inStyle bool
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return refs, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			inStyle = tokenType == html.StartTagToken && token.Data == "style"
+
+			for _, attr := range token.Attr {
+				switch {
+				case urlAttrs[attr.Key]:
+					asset := attr.Key != "href" || loadsResource(token)
+					refs = append(refs, fileRef{value: strings.TrimSpace(attr.Val), asset: asset})
+				case srcsetAttrs[attr.Key]:
+					for _, candidate := range strings.Split(attr.Val, ",") {
+						if fields := strings.Fields(candidate); len(fields) > 0 {
+							refs = append(refs, fileRef{value: fields[0], asset: true})
+						}
+					}
+				case attr.Key == "style":
+					refs = append(refs, cssRefs(attr.Val)...)
+				}
+			}
+		case html.EndTagToken:
+			inStyle = false
+		case html.TextToken:
+			if inStyle {
+				refs = append(refs, cssRefs(string(tokenizer.Text()))...)
+			}
+		}
+	}
+}
+
+func cssRefs(css string) []fileRef {
+	var refs []fileRef
+	for _, m := range cssURLRegexp.FindAllStringSubmatch(css, -1) {
+		refs = append(refs, fileRef{value: strings.TrimSpace(m[2]), asset: true})
+	}
+
+	return refs
+}
+
+// localRef resolves reference of the file to the path relative to the export root.
+// References with scheme or host, fragments and data URLs are not local.
+func localRef(name, ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(ref, "//") || u.Path == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(u.Path, "/") {
+		return path.Clean(strings.TrimPrefix(u.Path, "/")), true
+	}
+
+	return path.Join(path.Dir(name), u.Path), true
+}
+
+// checkAsset returns kind of the issue with the asset or empty string if the asset is fine.
+// Checksums of assets that are not listed in the manifest are not checked.
+func checkAsset(fsys fs.FS, manifest *Manifest, name string) VerifyIssueKind {
+	if !fs.ValidPath(name) {
+		return IssueMissingAsset
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return IssueMissingAsset
+	}
+	defer f.Close()
+
+	asset, ok := manifest.Asset(name)
+	if !ok {
+		return ""
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil || hex.EncodeToString(hash.Sum(nil)) != asset.SHA256 {
+		return IssueChecksumMismatch
+	}
+
+	return ""
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"context"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerify(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	registerTestProject(testPage("1", 10, ""), testPage("2", 20, "blog"))
+
+	root := t.TempDir()
+	_, err := Project(context.Background(), newTestClient(), "54321", NewDirStorage(root))
+	assert.NoError(t, err)
+
+	report, err := Verify(root)
+	assert.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.NoError(t, report.Err())
+	assert.Equal(t, 5, report.Files)
+	assert.Equal(t, 3, report.Assets)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "images", "photo2.jpg"), []byte("changed"), 0o644))
+	assert.NoError(t, os.Remove(filepath.Join(root, "css", "fonts-tildasans.css")))
+	assert.NoError(t, os.Remove(filepath.Join(root, "index.html")))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "page1.html"), []byte(`<html><head>`+
+		`<style>body{background:url('https://static.tildacdn.com/img/bg.png')}</style></head>`+
+		`<body><a href="blog">Blog</a><a href="https://tilda.cc">Tilda</a><img src="images/photo1.jpg" srcset="images/missing.jpg 2x"></body></html>`), 0o644))
+
+	report, err = Verify(root)
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []VerifyIssue{
+		{Kind: IssueMissingAsset, File: "blog/index.html", Ref: "../css/fonts-tildasans.css"},
+		{Kind: IssueChecksumMismatch, File: "blog/index.html", Ref: "../images/photo2.jpg"},
+		{Kind: IssueMissingPage, File: "index.html"},
+		{Kind: IssueCDNReference, File: "page1.html", Ref: "https://static.tildacdn.com/img/bg.png"},
+		{Kind: IssueMissingAsset, File: "page1.html", Ref: "images/missing.jpg"},
+		{Kind: IssueMissingAsset, File: "page2.html", Ref: "css/fonts-tildasans.css"},
+		{Kind: IssueChecksumMismatch, File: "page2.html", Ref: "images/photo2.jpg"},
+	}, report.Issues)
+	assert.ErrorIs(t, report.Err(), ErrVerificationFailed)
+}
+
+func TestVerifyFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		fsys       fstest.MapFS
+		wantIssues []VerifyIssue
+		wantErr    error
+	}{
+		{
+			name: "css",
+			fsys: fstest.MapFS{
+				ManifestFilename: {Data: []byte(`{"version":1,"pages":[],"assets":[]}`)},
+				"css/style.css":  {Data: []byte(`a{background:url(../images/bg.png)}b{background:url("/images/logo.svg#icon")}c{background:url(data:image/png;base64,AAAA)}`)},
+				"images/bg.png":  {Data: []byte("png")},
+			},
+			wantIssues: []VerifyIssue{
+				{Kind: IssueMissingAsset, File: "css/style.css", Ref: "/images/logo.svg#icon"},
+			},
+		}, {
+			name: "cdn references",
+			fsys: fstest.MapFS{
+				ManifestFilename: {Data: []byte(`{"version":1,"pages":[],"assets":[]}`)},
+				"page1.html": {Data: []byte(`<html><head>` +
+					`<link rel="dns-prefetch" href="https://ws.tildacdn.com">` +
+					`<link rel="preconnect" href="https://static.tildacdn.com" crossorigin>` +
+					`<link rel="preload" href="https://static.tildacdn.com/css/fonts-tildasans.css" as="style">` +
+					`<link rel="canonical" href="https://example.com/">` +
+					`<link rel="stylesheet" href="https://static.tildacdn.com/css/tilda-grid-3.0.min.css">` +
+					`<link rel="shortcut icon" href="https://static.tildacdn.com/img/tildafavicon.ico">` +
+					`<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"></script></head>` +
+					`<body><a href="https://static.tildacdn.com/doc/price.pdf">Price</a>` +
+					`<img src="images/photo.jpg" srcset="https://static.tildacdn.com/img/photo@2x.jpg 2x"></body></html>`)},
+				"images/photo.jpg": {Data: []byte("jpg")},
+			},
+			wantIssues: []VerifyIssue{
+				{Kind: IssueCDNReference, File: "page1.html", Ref: "https://static.tildacdn.com/css/tilda-grid-3.0.min.css"},
+				{Kind: IssueCDNReference, File: "page1.html", Ref: "https://static.tildacdn.com/img/photo@2x.jpg"},
+				{Kind: IssueCDNReference, File: "page1.html", Ref: "https://static.tildacdn.com/img/tildafavicon.ico"},
+				{Kind: IssueCDNReference, File: "page1.html", Ref: "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"},
+			},
+		}, {
+			name:    "no manifest",
+			fsys:    fstest.MapFS{},
+			wantErr: fs.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := VerifyFS(tt.fsys)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIssues, report.Issues)
+		})
+	}
+}
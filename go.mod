@@ -2,10 +2,15 @@ module github.com/dimuska139/tilda-go
 
 go 1.22.9
 
+require (
+	github.com/jarcoal/httpmock v1.3.1
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/image v0.23.0
+	golang.org/x/net v0.33.0
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/jarcoal/httpmock v1.3.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.10.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,107 @@
+package tilda_go
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrIDNotFound is returned when Tilda ID can't be found in the input
+var ErrIDNotFound = errors.New("tilda id not found")
+
+type (
+	// ProjectID represents ID of Tilda project
+	ProjectID string
+
+	// PageID represents ID of Tilda page
+	PageID string
+)
+
+var (
+	numericIDRegexp = regexp.MustCompile(`^[0-9]+$`)
+	pageIDRegexp    = regexp.MustCompile(`(?:^|[/_-])page([0-9]+)(?:\.html|\.min\.js|\.min\.css|\.js|\.css)(?:$|[?#])`)
+	projectIDRegexp = regexp.MustCompile(`/ws/project([0-9]+)(?:/|$)`)
+)
+
+// String returns project ID as string suitable for Client methods
+func (id ProjectID) String() string {
+	return string(id)
+}
+
+// String returns page ID as string suitable for Client methods
+func (id PageID) String() string {
+	return string(id)
+}
+
+// ParsePageID extracts page ID from numeric string, page filename (page12345.html)
+// or asset path (tilda-blocks-page12345.min.js) including full URLs
+func ParsePageID(s string) (PageID, error) {
+	s = strings.TrimSpace(s)
+	if numericIDRegexp.MatchString(s) {
+		return PageID(s), nil
+	}
+
+	if m := pageIDRegexp.FindStringSubmatch(s); m != nil {
+		return PageID(m[1]), nil
+	}
+
+	return "", fmt.Errorf("parse page id %q: %w", s, ErrIDNotFound)
+}
+
+// ParseProjectID extracts project ID from numeric string or asset path containing /ws/project54321/ segment
+func ParseProjectID(s string) (ProjectID, error) {
+	s = strings.TrimSpace(s)
+	if numericIDRegexp.MatchString(s) {
+		return ProjectID(s), nil
+	}
+
+	if m := projectIDRegexp.FindStringSubmatch(s); m != nil {
+		return ProjectID(m[1]), nil
+	}
+
+	return "", fmt.Errorf("parse project id %q: %w", s, ErrIDNotFound)
+}
+
+// ParseHTMLIDs extracts project and page IDs from data-tilda-project-id and data-tilda-page-id
+// attributes of #allrecords element
+func ParseHTMLIDs(r io.Reader) (ProjectID, PageID, error) {
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", "", fmt.Errorf("tokenize html: %w", err)
+			}
+
+			return "", "", fmt.Errorf("parse html: %w", ErrIDNotFound)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+
+			var isAllRecords bool
+			var projectID ProjectID
+			var pageID PageID
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case "id":
+					isAllRecords = attr.Val == "allrecords"
+				case "data-tilda-project-id":
+					projectID = ProjectID(attr.Val)
+				case "data-tilda-page-id":
+					pageID = PageID(attr.Val)
+				}
+			}
+
+			if isAllRecords {
+				if projectID == "" && pageID == "" {
+					return "", "", fmt.Errorf("parse #allrecords attributes: %w", ErrIDNotFound)
+				}
+
+				return projectID, pageID, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tilda_go
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParsePageID(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    PageID
+		wantErr bool
+	}{
+		{
+			name: "numeric",
+			s:    " 12345 ",
+			want: "12345",
+		}, {
+			name: "filename",
+			s:    "page12345.html",
+			want: "12345",
+		}, {
+			name: "page url",
+			s:    "https://example.com/page12345.html#rec1",
+			want: "12345",
+		}, {
+			name: "blocks js",
+			s:    "https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.js?t=1734259633",
+			want: "12345",
+		}, {
+			name: "blocks css",
+			s:    "tilda-blocks-page12345.min.css",
+			want: "12345",
+		}, {
+			name:    "project path",
+			s:       "/ws/project54321/",
+			wantErr: true,
+		}, {
+			name:    "empty",
+			s:       "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePageID(tt.s)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrIDNotFound)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseProjectID(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    ProjectID
+		wantErr bool
+	}{
+		{
+			name: "numeric",
+			s:    "54321",
+			want: "54321",
+		}, {
+			name: "ws segment",
+			s:    "/ws/project54321/",
+			want: "54321",
+		}, {
+			name: "asset url",
+			s:    "https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.js?t=1734259633",
+			want: "54321",
+		}, {
+			name:    "page filename",
+			s:       "page12345.html",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProjectID(tt.s)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrIDNotFound)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseHTMLIDs(t *testing.T) {
+	tests := []struct {
+		name          string
+		html          string
+		wantProjectID ProjectID
+		wantPageID    PageID
+		wantErr       bool
+	}{
+		{
+			name:          "body",
+			html:          `<!--allrecords--> <div id="allrecords" class="t-records" data-hook="blocks-collection-content-node" data-tilda-project-id="54321" data-tilda-page-id="12345" data-tilda-page-alias="blog"></div> <!--/allrecords-->`,
+			wantProjectID: "54321",
+			wantPageID:    "12345",
+		}, {
+			name:          "full document",
+			html:          `<!DOCTYPE html><html><head><title>Blog</title></head><body><div class="t-body"><div data-tilda-page-id="1" id="rec1"></div><div id="allrecords" data-tilda-page-id="12345" data-tilda-project-id="54321"></div></div></body></html>`,
+			wantProjectID: "54321",
+			wantPageID:    "12345",
+		}, {
+			name:    "no attributes",
+			html:    `<div id="allrecords"></div>`,
+			wantErr: true,
+		}, {
+			name:    "no allrecords",
+			html:    `<div data-tilda-page-id="12345"></div>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectID, pageID, err := ParseHTMLIDs(strings.NewReader(tt.html))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrIDNotFound)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantProjectID, projectID)
+			assert.Equal(t, tt.wantPageID, pageID)
+		})
+	}
+}
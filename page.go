@@ -7,12 +7,7 @@ import (
 
 // GetPage returns detailed page information with body HTML code
 func (c *Client) GetPage(ctx context.Context, pageID string) (Page, error) {
-	type Response struct {
-		Status string `json:"status"`
-		Result Page   `json:"result"`
-	}
-
-	var response Response
+	var response Response[Page]
 	if err := c.doRequest(ctx, "/v1/getpage/", map[string]any{
 		"pageid": pageID,
 	}, &response); err != nil {
@@ -24,12 +19,7 @@ func (c *Client) GetPage(ctx context.Context, pageID string) (Page, error) {
 
 // GetPageFull returns detailed page information without images, js and css but with full HTML code
 func (c *Client) GetPageFull(ctx context.Context, pageID string) (PageFull, error) {
-	type Response struct {
-		Status string   `json:"status"`
-		Result PageFull `json:"result"`
-	}
-
-	var response Response
+	var response Response[PageFull]
 	if err := c.doRequest(ctx, "/v1/getpagefull/", map[string]any{
 		"pageid": pageID,
 	}, &response); err != nil {
@@ -41,12 +31,7 @@ func (c *Client) GetPageFull(ctx context.Context, pageID string) (PageFull, erro
 
 // GetPageExport returns detailed page information for export with body HTML code
 func (c *Client) GetPageExport(ctx context.Context, pageID string) (PageExport, error) {
-	type Response struct {
-		Status string     `json:"status"`
-		Result PageExport `json:"result"`
-	}
-
-	var response Response
+	var response Response[PageExport]
 	if err := c.doRequest(ctx, "/v1/getpageexport/", map[string]any{
 		"pageid": pageID,
 	}, &response); err != nil {
@@ -58,12 +43,7 @@ func (c *Client) GetPageExport(ctx context.Context, pageID string) (PageExport,
 
 // GetPageFullExport returns detailed page information for export with full HTML code
 func (c *Client) GetPageFullExport(ctx context.Context, pageID string) (PageExport, error) {
-	type Response struct {
-		Status string     `json:"status"`
-		Result PageExport `json:"result"`
-	}
-
-	var response Response
+	var response Response[PageExport]
 	if err := c.doRequest(ctx, "/v1/getpagefullexport/", map[string]any{
 		"pageid": pageID,
 	}, &response); err != nil {
@@ -7,12 +7,7 @@ import (
 
 // GetProjectsList returns the list of projects
 func (c *Client) GetProjectsList(ctx context.Context) ([]Project, error) {
-	type Response struct {
-		Status string    `json:"status"`
-		Result []Project `json:"result"`
-	}
-
-	var response Response
+	var response Response[[]Project]
 	if err := c.doRequest(ctx, "/v1/getprojectslist/", nil, &response); err != nil {
 		return nil, fmt.Errorf("do request: %w", err)
 	}
@@ -22,12 +17,7 @@ func (c *Client) GetProjectsList(ctx context.Context) ([]Project, error) {
 
 // GetProjectInfo returns detailed project information
 func (c *Client) GetProjectInfo(ctx context.Context, projectID string) (Project, error) {
-	type Response struct {
-		Status string  `json:"status"`
-		Result Project `json:"result"`
-	}
-
-	var response Response
+	var response Response[Project]
 	if err := c.doRequest(ctx, "/v1/getprojectinfo/", map[string]any{
 		"projectid": projectID,
 	}, &response); err != nil {
@@ -37,14 +27,21 @@ func (c *Client) GetProjectInfo(ctx context.Context, projectID string) (Project,
 	return response.Result, nil
 }
 
-// GetProjectPages returns the list of pages for the project
-func (c *Client) GetProjectPages(ctx context.Context, projectID string) ([]Page, error) {
-	type Response struct {
-		Status string `json:"status"`
-		Result []Page `json:"result"`
+// GetProjectExport returns detailed project information for export
+func (c *Client) GetProjectExport(ctx context.Context, projectID string) (ProjectInfo, error) {
+	var response Response[ProjectInfo]
+	if err := c.doRequest(ctx, "/v1/getprojectexport/", map[string]any{
+		"projectid": projectID,
+	}, &response); err != nil {
+		return ProjectInfo{}, fmt.Errorf("do request: %w", err)
 	}
 
-	var response Response
+	return response.Result, nil
+}
+
+// GetProjectPages returns the list of pages for the project
+func (c *Client) GetProjectPages(ctx context.Context, projectID string) ([]Page, error) {
+	var response Response[[]Page]
 	if err := c.doRequest(ctx, "/v1/getpageslist/", map[string]any{
 		"projectid": projectID,
 	}, &response); err != nil {
@@ -241,6 +241,137 @@ func TestClient_GetProjectInfo(t *testing.T) {
 	}
 }
 
+func TestClient_GetProjectExport(t *testing.T) {
+	type fields struct {
+		config     *Config
+		httpClient *http.Client
+		baseURL    string
+	}
+	type args struct {
+		ctx       context.Context
+		projectID string
+	}
+	tests := []struct {
+		name              string
+		fields            fields
+		args              args
+		stubFilename      string
+		registerResponder func(responseBody []byte)
+		want              ProjectInfo
+		wantErr           bool
+	}{
+		{
+			name: "success",
+			fields: fields{
+				config: &Config{
+					PublicKey: "public",
+					SecretKey: "secret",
+				},
+				httpClient: http.DefaultClient,
+				baseURL:    apiBaseUrl,
+			},
+			args: args{
+				ctx:       context.Background(),
+				projectID: "123",
+			},
+			stubFilename: "project_export.json",
+			registerResponder: func(responseBody []byte) {
+				url := fmt.Sprintf("%s/v1/getprojectexport/?projectid=123&publickey=public&secretkey=secret", apiBaseUrl)
+
+				httpmock.RegisterResponder(http.MethodGet, url,
+					func(req *http.Request) (*http.Response, error) {
+						resp := httpmock.NewBytesResponse(http.StatusOK, responseBody)
+
+						return resp, nil
+					},
+				)
+			},
+			want: ProjectInfo{
+				ID:           "12345",
+				UserID:       "54321",
+				Date:         DateTime(time.Date(2024, 12, 14, 19, 6, 45, 0, time.UTC)),
+				Title:        "My Site",
+				Description:  "Description of My Site",
+				Sort:         "1",
+				Alias:        "mysiteqwerty",
+				IndexpageID:  "600312345",
+				HeaderpageID: "0",
+				FooterpageID: "0",
+				HeadlineFont: "TildaSans",
+				TextFont:     "TildaSans",
+				FormsKey:     "qwerty",
+				Page404ID:    "0",
+				CntFolders:   "0",
+				CntCollabs:   "0",
+				Changed:      "1734258300",
+				Images: []Image{
+					{
+						From: "https://static.tildacdn.com/img/tildafavicon.ico",
+						To:   "tildafavicon.ico",
+					},
+				},
+			},
+		}, {
+			name: "failed",
+			fields: fields{
+				config: &Config{
+					PublicKey: "public",
+					SecretKey: "secret",
+				},
+				httpClient: http.DefaultClient,
+				baseURL:    apiBaseUrl,
+			},
+			args: args{
+				ctx:       context.Background(),
+				projectID: "123",
+			},
+			stubFilename: "project_export.json",
+			registerResponder: func(_ []byte) {
+				url := fmt.Sprintf("%s/v1/getprojectexport/?projectid=123&publickey=public&secretkey=secret", apiBaseUrl)
+
+				httpmock.RegisterResponder(http.MethodGet, url,
+					func(req *http.Request) (*http.Response, error) {
+						resp := httpmock.NewBytesResponse(http.StatusOK, []byte("{"))
+
+						return resp, nil
+					},
+				)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			var html []byte
+			if tt.stubFilename != "" {
+				bts, err := os.ReadFile(fmt.Sprintf("stub/%s", tt.stubFilename))
+				assert.NoError(t, err)
+
+				html = bts
+			}
+
+			tt.registerResponder(html)
+
+			c := &Client{
+				config:     tt.fields.config,
+				httpClient: tt.fields.httpClient,
+				baseURL:    tt.fields.baseURL,
+			}
+			got, err := c.GetProjectExport(tt.args.ctx, tt.args.projectID)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestClient_GetProjectPages(t *testing.T) {
 	type fields struct {
 		config     *Config
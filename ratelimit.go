@@ -0,0 +1,116 @@
+package tilda_go
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// clock is the source of time for rate limiting and scheduling (replaced in tests)
+type clock interface {
+	Now() time.Time
+	// NewTimer returns the channel receiving time after d and the function stopping the timer
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+}
+
+// realClock is the clock of time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+
+	return timer.C, timer.Stop
+}
+
+// rateLimiter allows limit requests per fixed interval the same way Tilda counts its quota
+type rateLimiter struct {
+	mu          sync.Mutex
+	clock       clock
+	limit       int
+	interval    time.Duration
+	windowStart time.Time
+	used        int
+}
+
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		clock:    realClock{},
+		limit:    limit,
+		interval: interval,
+	}
+}
+
+// wait blocks until request can be made and takes it from the quota
+func (l *rateLimiter) wait(ctx context.Context) error {
+	return l.await(ctx, true)
+}
+
+// waitAvailable blocks until request can be made without taking it from the quota
+func (l *rateLimiter) waitAvailable(ctx context.Context) error {
+	return l.await(ctx, false)
+}
+
+func (l *rateLimiter) await(ctx context.Context, take bool) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	for {
+		l.mu.Lock()
+		now := l.clock.Now()
+		l.advance(now)
+		if l.used < l.limit {
+			if take {
+				l.used++
+			}
+			l.mu.Unlock()
+
+			return nil
+		}
+		reset := l.windowStart.Add(l.interval)
+		l.mu.Unlock()
+
+		timer, stop := l.clock.NewTimer(reset.Sub(now))
+		select {
+		case <-ctx.Done():
+			stop()
+			return ctx.Err()
+		case <-timer:
+		}
+	}
+}
+
+// remaining returns the number of requests left in the current interval and the moment the quota resets
+func (l *rateLimiter) remaining() (int, time.Time) {
+	if l == nil {
+		return math.MaxInt, time.Time{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if l.windowStart.IsZero() || !now.Before(l.windowStart.Add(l.interval)) {
+		return l.limit, now.Add(l.interval)
+	}
+
+	return l.limit - l.used, l.windowStart.Add(l.interval)
+}
+
+func (l *rateLimiter) advance(now time.Time) {
+	if l.windowStart.IsZero() || !now.Before(l.windowStart.Add(l.interval)) {
+		l.windowStart = now
+		l.used = 0
+	}
+}
+
+// RateLimitRemaining returns the number of requests left in the current rate limit interval
+// and the moment the limit resets (math.MaxInt and zero time if client has no rate limit)
+func (c *Client) RateLimitRemaining() (int, time.Time) {
+	return c.limiter.remaining()
+}
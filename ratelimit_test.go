@@ -0,0 +1,178 @@
+package tilda_go
+
+import (
+	"context"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is the clock advanced manually by tests
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 12, 15, 13, 20, 30, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer.c, func() bool { return false }
+	}
+	c.timers = append(c.timers, timer)
+
+	return timer.c, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		for i, t := range c.timers {
+			if t == timer {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Advance moves the clock forward and fires timers that are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	kept := c.timers[:0]
+	for _, timer := range c.timers {
+		if c.now.Before(timer.at) {
+			kept = append(kept, timer)
+			continue
+		}
+		timer.c <- c.now
+	}
+	c.timers = kept
+}
+
+// Waiters returns the number of active timers
+func (c *fakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// waitForTimer waits until some goroutine blocks on the timer of the clock
+func waitForTimer(t *testing.T, clock *fakeClock) {
+	assert.Eventually(t, func() bool {
+		return clock.Waiters() > 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestRateLimiter_wait(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newRateLimiter(2, 100*time.Millisecond)
+	limiter.clock = clock
+	start := clock.Now()
+
+	remaining, _ := limiter.remaining()
+	assert.Equal(t, 2, remaining)
+
+	assert.NoError(t, limiter.wait(context.Background()))
+	clock.Advance(10 * time.Millisecond)
+	assert.NoError(t, limiter.wait(context.Background()))
+
+	remaining, reset := limiter.remaining()
+	assert.Equal(t, 0, remaining)
+	assert.Equal(t, start.Add(100*time.Millisecond), reset)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- limiter.wait(ctx)
+	}()
+	waitForTimer(t, clock)
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	assert.Zero(t, clock.Waiters())
+
+	go func() {
+		errs <- limiter.waitAvailable(context.Background())
+	}()
+	waitForTimer(t, clock)
+	clock.Advance(80 * time.Millisecond)
+	select {
+	case err := <-errs:
+		t.Fatalf("waitAvailable returned before the interval ended: %v", err)
+	default:
+	}
+	clock.Advance(10 * time.Millisecond)
+	assert.NoError(t, <-errs)
+
+	remaining, reset = limiter.remaining()
+	assert.Equal(t, 2, remaining)
+	assert.Equal(t, start.Add(200*time.Millisecond), reset)
+}
+
+func TestRateLimiter_nil(t *testing.T) {
+	var limiter *rateLimiter
+
+	assert.NoError(t, limiter.wait(context.Background()))
+
+	remaining, reset := limiter.remaining()
+	assert.Equal(t, math.MaxInt, remaining)
+	assert.True(t, reset.IsZero())
+}
+
+func TestClient_RateLimitRemaining(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet,
+		fmt.Sprintf("%s/v1/getprojectslist/?publickey=public&secretkey=secret", apiBaseUrl),
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse([]Project{})))
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	}, WithRateLimit(1, time.Hour))
+	clock := newFakeClock()
+	c.limiter.clock = clock
+
+	_, err := c.GetProjectsList(context.Background())
+	assert.NoError(t, err)
+
+	remaining, reset := c.RateLimitRemaining()
+	assert.Equal(t, 0, remaining)
+	assert.Equal(t, clock.Now().Add(time.Hour), reset)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.GetProjectsList(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,18 @@
+package tilda_go
+
+// StatusFound is the status of successful Tilda API response
+const StatusFound = "FOUND"
+
+// Response represents the envelope of Tilda API response
+type Response[T any] struct {
+	Status string `json:"status"`
+	Result T      `json:"result"`
+}
+
+// NewResponse wraps result into the envelope the same way Tilda API does, so it can be re-served and decoded by Client
+func NewResponse[T any](result T) Response[T] {
+	return Response[T]{
+		Status: StatusFound,
+		Result: result,
+	}
+}
@@ -0,0 +1,126 @@
+package tilda_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func testRoundTrip[T any](t *testing.T, stubFilename string) {
+	bts, err := os.ReadFile(fmt.Sprintf("stub/%s", stubFilename))
+	assert.NoError(t, err)
+
+	var response Response[T]
+	assert.NoError(t, json.Unmarshal(bts, &response))
+
+	encoded, err := json.Marshal(NewResponse(response.Result))
+	assert.NoError(t, err)
+
+	var want, got any
+	assert.NoError(t, json.Unmarshal(bts, &want))
+	assert.NoError(t, json.Unmarshal(encoded, &got))
+	assertWireEqual(t, "", want, got)
+
+	var decoded Response[T]
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, response, decoded)
+}
+
+// assertWireEqual checks that every key Tilda returned is encoded with the same value. Keys Tilda omits
+// (e.g. html of pages in the list) are encoded with zero values.
+func assertWireEqual(t *testing.T, path string, want, got any) {
+	wantMap, ok := want.(map[string]any)
+	if !ok {
+		if wantSlice, ok := want.([]any); ok {
+			gotSlice, ok := got.([]any)
+			if assert.True(t, ok, path) && assert.Len(t, gotSlice, len(wantSlice), path) {
+				for i := range wantSlice {
+					assertWireEqual(t, fmt.Sprintf("%s[%d]", path, i), wantSlice[i], gotSlice[i])
+				}
+			}
+			return
+		}
+
+		assert.Equal(t, want, got, path)
+		return
+	}
+
+	gotMap, ok := got.(map[string]any)
+	if !assert.True(t, ok, path) {
+		return
+	}
+	for key, value := range wantMap {
+		gotValue, ok := gotMap[key]
+		if assert.True(t, ok, "%s.%s is missing", path, key) {
+			assertWireEqual(t, path+"."+key, value, gotValue)
+		}
+	}
+	for key, value := range gotMap {
+		if _, ok := wantMap[key]; !ok {
+			assert.Empty(t, value, "%s.%s is not returned by Tilda", path, key)
+		}
+	}
+}
+
+func TestPage_MarshalJSON_EmptyFields(t *testing.T) {
+	var page Page
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":"12345","html":"","js":[],"css":[]}`), &page))
+
+	encoded, err := json.Marshal(page)
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(encoded, &got))
+	assert.Equal(t, "", got["html"])
+	assert.Equal(t, []any{}, got["js"])
+	assert.Equal(t, []any{}, got["css"])
+
+	encoded, err = json.Marshal(JS{From: "https://static.tildacdn.com/js/tilda-stat-1.0.min.js", To: "tilda-stat-1.0.min.js", Attrs: []string{}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"from":"https://static.tildacdn.com/js/tilda-stat-1.0.min.js","to":"tilda-stat-1.0.min.js","attrs":[]}`, string(encoded))
+}
+
+func TestResponse_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		stubFilename string
+		roundTrip    func(t *testing.T, stubFilename string)
+	}{
+		{
+			name:         "projects list",
+			stubFilename: "projects_list.json",
+			roundTrip:    testRoundTrip[[]Project],
+		}, {
+			name:         "project info",
+			stubFilename: "project.json",
+			roundTrip:    testRoundTrip[ProjectInfo],
+		}, {
+			name:         "pages list",
+			stubFilename: "pages_list.json",
+			roundTrip:    testRoundTrip[[]Page],
+		}, {
+			name:         "page",
+			stubFilename: "page.json",
+			roundTrip:    testRoundTrip[Page],
+		}, {
+			name:         "page full",
+			stubFilename: "page_full.json",
+			roundTrip:    testRoundTrip[PageFull],
+		}, {
+			name:         "page export",
+			stubFilename: "page_export.json",
+			roundTrip:    testRoundTrip[PageExport],
+		}, {
+			name:         "page full export",
+			stubFilename: "page_export_full.json",
+			roundTrip:    testRoundTrip[PageExport],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.roundTrip(t, tt.stubFilename)
+		})
+	}
+}
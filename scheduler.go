@@ -0,0 +1,253 @@
+package tilda_go
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrDeadlineExceeded is returned for scheduled page request that was not made before its deadline
+var ErrDeadlineExceeded = errors.New("page request deadline exceeded")
+
+// Priorities of page requests used by PagePriority
+const (
+	PriorityNormal  = 0
+	PriorityRecent  = 10 // Recently published page
+	PriorityIndex   = 20 // Index page of the project
+	PriorityWebhook = 30 // Page flagged by webhook
+)
+
+// PageRequest represents request to fetch page queued in Scheduler
+type PageRequest struct {
+	PageID   string
+	Variant  PageVariant // Endpoint used to get page details
+	Priority int         // Requests with higher priority are made first
+	Deadline time.Time   // Request is dropped if it can't be made before deadline (no deadline if zero)
+}
+
+// ScheduledPage represents result of scheduled page request
+type ScheduledPage struct {
+	Request PageRequest
+	CrawledPage
+	Err error // Error of request or ErrDeadlineExceeded
+}
+
+// PagePriority returns priority of page: index page of the project first, then pages published during recent period
+func PagePriority(page Page, project ProjectInfo, recent time.Duration) int {
+	if page.ID == project.IndexpageID {
+		return PriorityIndex
+	}
+
+	if time.Since(time.Unix(int64(page.Published), 0)) <= recent {
+		return PriorityRecent
+	}
+
+	return PriorityNormal
+}
+
+// Scheduler queues page requests and makes them one by one by priority within the client rate limit.
+// Requests that don't fit into the current rate limit interval wait for the next one.
+type Scheduler struct {
+	client *Client
+	clock  clock
+	mu     sync.Mutex
+	queue  requestQueue
+	index  map[requestKey]*queuedRequest
+	seq    int
+	wakeup chan struct{}
+}
+
+type requestKey struct {
+	pageID  string
+	variant PageVariant
+}
+
+type queuedRequest struct {
+	request PageRequest
+	seq     int
+	index   int
+}
+
+// NewScheduler creates new scheduler of page requests made by client
+func NewScheduler(client *Client) *Scheduler {
+	return &Scheduler{
+		client: client,
+		clock:  realClock{},
+		index:  make(map[requestKey]*queuedRequest),
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+// Schedule queues page request. If the same page is already queued with the same variant,
+// the requests are merged keeping the highest priority and the earliest deadline.
+func (s *Scheduler) Schedule(request PageRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := requestKey{pageID: request.PageID, variant: request.Variant}
+	if queued, ok := s.index[key]; ok {
+		queued.request.Priority = max(queued.request.Priority, request.Priority)
+		if queued.request.Deadline.IsZero() ||
+			(!request.Deadline.IsZero() && request.Deadline.Before(queued.request.Deadline)) {
+			queued.request.Deadline = request.Deadline
+		}
+		heap.Fix(&s.queue, queued.index)
+
+		return
+	}
+
+	s.seq++
+	queued := &queuedRequest{request: request, seq: s.seq}
+	heap.Push(&s.queue, queued)
+	s.index[key] = queued
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Len returns the number of queued requests
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.queue.Len()
+}
+
+// Run makes queued requests and calls fn with every result including failed and expired requests.
+// Run waits for new requests when the queue is empty and returns when ctx is done or fn returns an error.
+func (s *Scheduler) Run(ctx context.Context, fn func(ScheduledPage) error) error {
+	for {
+		for _, expired := range s.dropExpired(s.clock.Now()) {
+			if err := fn(ScheduledPage{Request: expired, Err: ErrDeadlineExceeded}); err != nil {
+				return err
+			}
+		}
+
+		if s.Len() == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-s.wakeup:
+				continue
+			}
+		}
+
+		if err := s.client.limiter.waitAvailable(ctx); err != nil {
+			return err
+		}
+
+		request, ok := s.pop()
+		if !ok {
+			continue
+		}
+
+		if isExpired(request, s.clock.Now()) {
+			if err := fn(ScheduledPage{Request: request, Err: ErrDeadlineExceeded}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		crawled, err := s.client.getCrawledPage(ctx, Project{}, Page{ID: request.PageID}, request.Variant)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := fn(ScheduledPage{Request: request, CrawledPage: crawled, Err: err}); err != nil {
+			return err
+		}
+	}
+}
+
+// pop removes the request with the highest priority
+func (s *Scheduler) pop() (PageRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.queue.Len() == 0 {
+		return PageRequest{}, false
+	}
+
+	queued := heap.Pop(&s.queue).(*queuedRequest)
+	delete(s.index, requestKey{pageID: queued.request.PageID, variant: queued.request.Variant})
+
+	return queued.request, true
+}
+
+// dropExpired removes requests whose deadline has passed
+func (s *Scheduler) dropExpired(now time.Time) []PageRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []PageRequest
+	kept := s.queue[:0]
+	for _, queued := range s.queue {
+		if isExpired(queued.request, now) {
+			delete(s.index, requestKey{pageID: queued.request.PageID, variant: queued.request.Variant})
+			expired = append(expired, queued.request)
+			continue
+		}
+
+		queued.index = len(kept)
+		kept = append(kept, queued)
+	}
+	s.queue = kept
+	heap.Init(&s.queue)
+
+	return expired
+}
+
+func isExpired(request PageRequest, now time.Time) bool {
+	return !request.Deadline.IsZero() && !now.Before(request.Deadline)
+}
+
+// requestQueue implements heap.Interface ordering requests by priority, then deadline, then scheduling order
+type requestQueue []*queuedRequest
+
+func (q requestQueue) Len() int {
+	return len(q)
+}
+
+func (q requestQueue) Less(i, j int) bool {
+	a, b := q[i].request, q[j].request
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	if !a.Deadline.Equal(b.Deadline) {
+		if a.Deadline.IsZero() || b.Deadline.IsZero() {
+			return b.Deadline.IsZero()
+		}
+
+		return a.Deadline.Before(b.Deadline)
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q requestQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *requestQueue) Push(x any) {
+	queued := x.(*queuedRequest)
+	queued.index = len(*q)
+	*q = append(*q, queued)
+}
+
+func (q *requestQueue) Pop() any {
+	old := *q
+	n := len(old)
+	queued := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return queued
+}
@@ -0,0 +1,139 @@
+package tilda_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPagePriority(t *testing.T) {
+	project := ProjectInfo{IndexpageID: "1"}
+
+	tests := []struct {
+		name string
+		page Page
+		want int
+	}{
+		{
+			name: "index",
+			page: Page{ID: "1", Published: int(time.Now().Unix())},
+			want: PriorityIndex,
+		}, {
+			name: "recent",
+			page: Page{ID: "2", Published: int(time.Now().Add(-time.Hour).Unix())},
+			want: PriorityRecent,
+		}, {
+			name: "old",
+			page: Page{ID: "3", Published: int(time.Now().Add(-48 * time.Hour).Unix())},
+			want: PriorityNormal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PagePriority(tt.page, project, 24*time.Hour))
+		})
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	for _, pageID := range []string{"1", "2", "3", "4"} {
+		httpmock.RegisterResponder(http.MethodGet,
+			fmt.Sprintf("%s/v1/getpage/?pageid=%s&publickey=public&secretkey=secret", apiBaseUrl, pageID),
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(Page{ID: pageID})))
+	}
+	registerPageExport("getpageexport", PageExport{ID: "3"})
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	}, WithRateLimit(2, 150*time.Millisecond))
+	clock := newFakeClock()
+	c.limiter.clock = clock
+
+	scheduler := NewScheduler(c)
+	scheduler.clock = clock
+	scheduler.Schedule(PageRequest{PageID: "1", Priority: PriorityNormal})
+	scheduler.Schedule(PageRequest{PageID: "2", Priority: PriorityIndex})
+	scheduler.Schedule(PageRequest{PageID: "3", Variant: PageVariantExport, Priority: PriorityRecent})
+	scheduler.Schedule(PageRequest{PageID: "4", Priority: PriorityNormal, Deadline: clock.Now().Add(50 * time.Millisecond)})
+	scheduler.Schedule(PageRequest{PageID: "1", Priority: PriorityWebhook})
+	assert.Equal(t, 4, scheduler.Len())
+
+	// The first two requests use the quota of the interval, the rest wait for the next one
+	start := clock.Now()
+	go func() {
+		waitForTimer(t, clock)
+		clock.Advance(150 * time.Millisecond)
+	}()
+
+	errDone := errors.New("done")
+	var got []string
+	var expired []string
+	var elapsed time.Duration
+	err := scheduler.Run(context.Background(), func(page ScheduledPage) error {
+		if errors.Is(page.Err, ErrDeadlineExceeded) {
+			expired = append(expired, page.Request.PageID)
+		} else {
+			assert.NoError(t, page.Err)
+			if page.Export != nil {
+				got = append(got, page.Export.ID)
+			} else {
+				got = append(got, page.Page.ID)
+			}
+		}
+
+		if len(got)+len(expired) == 4 {
+			elapsed = clock.Now().Sub(start)
+			return errDone
+		}
+
+		return nil
+	})
+	assert.ErrorIs(t, err, errDone)
+	assert.Equal(t, []string{"1", "2", "3"}, got)
+	assert.Equal(t, []string{"4"}, expired)
+	assert.Equal(t, 150*time.Millisecond, elapsed)
+	assert.Equal(t, 0, scheduler.Len())
+}
+
+func TestScheduler_Run_Wait(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(http.MethodGet,
+		fmt.Sprintf("%s/v1/getpagefull/?pageid=1&publickey=public&secretkey=secret", apiBaseUrl),
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, NewResponse(PageFull{ID: "1"})))
+
+	c := NewClient(&Config{
+		PublicKey: "public",
+		SecretKey: "secret",
+	})
+	scheduler := NewScheduler(c)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		scheduler.Schedule(PageRequest{PageID: "1", Variant: PageVariantFull})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var got []string
+	err := scheduler.Run(ctx, func(page ScheduledPage) error {
+		assert.NoError(t, page.Err)
+		got = append(got, page.Full.ID)
+		cancel()
+
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"1"}, got)
+}
@@ -0,0 +1,137 @@
+package tilda_go
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"html/template"
+	"path"
+	"sort"
+	"strings"
+)
+
+// TagOptions allows to customize rendering of script and stylesheet tags
+type TagOptions struct {
+	Local       bool              // Reference local copies of assets instead of Tilda CDN URLs
+	BasePath    string            // Prefix of local asset paths (e.g. "js/" or "/static/")
+	Integrity   map[string]string // SRI hashes of assets keyed by source URL
+	CrossOrigin string            // Value of crossorigin attribute (tags with integrity get "anonymous" if not set)
+	Nonce       string            // CSP nonce added to every tag
+	Attrs       map[string]string // Extra attributes added to every tag
+}
+
+// RenderScripts renders script tags for JS assets of exported page in the order Tilda specifies
+func RenderScripts(scripts []JS, opts TagOptions) template.HTML {
+	tags := make([]string, 0, len(scripts))
+	for _, js := range scripts {
+		tags = append(tags, renderTag("script", "src", js.From, js.To, js.Attrs, opts))
+	}
+
+	return template.HTML(strings.Join(tags, "\n"))
+}
+
+// RenderStylesheets renders stylesheet link tags for CSS assets of exported page in the order Tilda specifies
+func RenderStylesheets(styles []CSS, opts TagOptions) template.HTML {
+	tags := make([]string, 0, len(styles))
+	for _, css := range styles {
+		tags = append(tags, renderTag("link", "href", css.From, css.To, nil, opts))
+	}
+
+	return template.HTML(strings.Join(tags, "\n"))
+}
+
+// RenderScriptURLs renders script tags for the list of JS URLs (Page.JS)
+func RenderScriptURLs(urls []string, opts TagOptions) template.HTML {
+	tags := make([]string, 0, len(urls))
+	for _, u := range urls {
+		tags = append(tags, renderTag("script", "src", u, AssetFilename(u), nil, opts))
+	}
+
+	return template.HTML(strings.Join(tags, "\n"))
+}
+
+// RenderStylesheetURLs renders stylesheet link tags for the list of CSS URLs (Page.CSS)
+func RenderStylesheetURLs(urls []string, opts TagOptions) template.HTML {
+	tags := make([]string, 0, len(urls))
+	for _, u := range urls {
+		tags = append(tags, renderTag("link", "href", u, AssetFilename(u), nil, opts))
+	}
+
+	return template.HTML(strings.Join(tags, "\n"))
+}
+
+// CrossOriginAnonymous is crossorigin attribute value required by browsers to check integrity of assets from other origins
+const CrossOriginAnonymous = "anonymous"
+
+// IntegrityHash returns Subresource Integrity value of the asset content (e.g. "sha384-...")
+func IntegrityHash(content []byte) string {
+	sum := sha512.Sum384(content)
+
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// AssetFilename returns the name of local file for asset URL the same way Tilda does in export
+// (the last path segment without query string)
+func AssetFilename(assetURL string) string {
+	if i := strings.IndexAny(assetURL, "?#"); i >= 0 {
+		assetURL = assetURL[:i]
+	}
+
+	return path.Base(assetURL)
+}
+
+func renderTag(name, urlAttr, from, to string, attrs []string, opts TagOptions) string {
+	src := from
+	if opts.Local {
+		src = opts.BasePath + to
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + name)
+	if name == "link" {
+		b.WriteString(` rel="stylesheet"`)
+	}
+	writeAttr(&b, urlAttr, src)
+
+	for _, attr := range attrs {
+		key, value, hasValue := strings.Cut(attr, "=")
+		if hasValue {
+			writeAttr(&b, key, strings.Trim(value, `"'`))
+		} else {
+			b.WriteString(" " + template.HTMLEscapeString(key))
+		}
+	}
+
+	crossOrigin := opts.CrossOrigin
+	if integrity, ok := opts.Integrity[from]; ok {
+		writeAttr(&b, "integrity", integrity)
+		if crossOrigin == "" {
+			crossOrigin = CrossOriginAnonymous
+		}
+	}
+	if crossOrigin != "" {
+		writeAttr(&b, "crossorigin", crossOrigin)
+	}
+	if opts.Nonce != "" {
+		writeAttr(&b, "nonce", opts.Nonce)
+	}
+
+	keys := make([]string, 0, len(opts.Attrs))
+	for key := range opts.Attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeAttr(&b, key, opts.Attrs[key])
+	}
+
+	b.WriteString(">")
+	if name == "script" {
+		b.WriteString("</script>")
+	}
+
+	return b.String()
+}
+
+func writeAttr(b *strings.Builder, key, value string) {
+	b.WriteString(" " + template.HTMLEscapeString(key) + `="` + template.HTMLEscapeString(value) + `"`)
+}
@@ -0,0 +1,201 @@
+package tilda_go
+
+import (
+	"github.com/stretchr/testify/assert"
+	"html/template"
+	"testing"
+)
+
+func TestRenderScripts(t *testing.T) {
+	scripts := []JS{
+		{
+			From:  "https://static.tildacdn.com/js/tilda-polyfill-1.0.min.js",
+			To:    "tilda-polyfill-1.0.min.js",
+			Attrs: []string{"nomodule"},
+		}, {
+			From:  "https://static.tildacdn.com/js/tilda-scripts-3.0.min.js",
+			To:    "tilda-scripts-3.0.min.js",
+			Attrs: []string{"defer"},
+		}, {
+			From: "https://static.tildacdn.com/js/tilda-stat-1.0.min.js",
+			To:   "tilda-stat-1.0.min.js",
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts TagOptions
+		want template.HTML
+	}{
+		{
+			name: "cdn",
+			want: `<script src="https://static.tildacdn.com/js/tilda-polyfill-1.0.min.js" nomodule></script>
+<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js" defer></script>
+<script src="https://static.tildacdn.com/js/tilda-stat-1.0.min.js"></script>`,
+		}, {
+			name: "local",
+			opts: TagOptions{
+				Local:    true,
+				BasePath: "/js/",
+			},
+			want: `<script src="/js/tilda-polyfill-1.0.min.js" nomodule></script>
+<script src="/js/tilda-scripts-3.0.min.js" defer></script>
+<script src="/js/tilda-stat-1.0.min.js"></script>`,
+		}, {
+			name: "extra attributes",
+			opts: TagOptions{
+				Integrity: map[string]string{
+					"https://static.tildacdn.com/js/tilda-stat-1.0.min.js": "sha384-abc",
+				},
+				CrossOrigin: "anonymous",
+				Nonce:       "r4nd\"om",
+				Attrs: map[string]string{
+					"referrerpolicy": "no-referrer",
+					"data-cfasync":   "false",
+				},
+			},
+			want: `<script src="https://static.tildacdn.com/js/tilda-polyfill-1.0.min.js" nomodule crossorigin="anonymous" nonce="r4nd&#34;om" data-cfasync="false" referrerpolicy="no-referrer"></script>
+<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js" defer crossorigin="anonymous" nonce="r4nd&#34;om" data-cfasync="false" referrerpolicy="no-referrer"></script>
+<script src="https://static.tildacdn.com/js/tilda-stat-1.0.min.js" integrity="sha384-abc" crossorigin="anonymous" nonce="r4nd&#34;om" data-cfasync="false" referrerpolicy="no-referrer"></script>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RenderScripts(scripts, tt.opts))
+		})
+	}
+}
+
+func TestRenderStylesheets(t *testing.T) {
+	styles := []CSS{
+		{
+			From: "https://static.tildacdn.com/css/tilda-popup-1.1.min.css",
+			To:   "tilda-popup-1.1.min.css",
+		}, {
+			From: "https://static.tildacdn.com/css/fonts-tildasans.css",
+			To:   "fonts-tildasans.css",
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts TagOptions
+		want template.HTML
+	}{
+		{
+			name: "cdn",
+			want: `<link rel="stylesheet" href="https://static.tildacdn.com/css/tilda-popup-1.1.min.css">
+<link rel="stylesheet" href="https://static.tildacdn.com/css/fonts-tildasans.css">`,
+		}, {
+			name: "local",
+			opts: TagOptions{
+				Local:       true,
+				BasePath:    "css/",
+				CrossOrigin: "anonymous",
+			},
+			want: `<link rel="stylesheet" href="css/tilda-popup-1.1.min.css" crossorigin="anonymous">
+<link rel="stylesheet" href="css/fonts-tildasans.css" crossorigin="anonymous">`,
+		}, {
+			name: "integrity",
+			opts: TagOptions{
+				Integrity: map[string]string{
+					"https://static.tildacdn.com/css/fonts-tildasans.css": "sha384-abc",
+				},
+			},
+			want: `<link rel="stylesheet" href="https://static.tildacdn.com/css/tilda-popup-1.1.min.css">
+<link rel="stylesheet" href="https://static.tildacdn.com/css/fonts-tildasans.css" integrity="sha384-abc" crossorigin="anonymous">`,
+		}, {
+			name: "local integrity",
+			opts: TagOptions{
+				Local:    true,
+				BasePath: "css/",
+				Integrity: map[string]string{
+					"https://static.tildacdn.com/css/fonts-tildasans.css": "sha384-abc",
+				},
+				CrossOrigin: "use-credentials",
+			},
+			want: `<link rel="stylesheet" href="css/tilda-popup-1.1.min.css" crossorigin="use-credentials">
+<link rel="stylesheet" href="css/fonts-tildasans.css" integrity="sha384-abc" crossorigin="use-credentials">`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RenderStylesheets(styles, tt.opts))
+		})
+	}
+}
+
+func TestRenderScriptURLs(t *testing.T) {
+	urls := []string{
+		"https://static.tildacdn.com/js/tilda-scripts-3.0.min.js",
+		"https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.js?t=1734259633",
+	}
+
+	tests := []struct {
+		name string
+		opts TagOptions
+		want template.HTML
+	}{
+		{
+			name: "cdn",
+			want: `<script src="https://static.tildacdn.com/js/tilda-scripts-3.0.min.js"></script>
+<script src="https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.js?t=1734259633"></script>`,
+		}, {
+			name: "local",
+			opts: TagOptions{
+				Local:    true,
+				BasePath: "js/",
+			},
+			want: `<script src="js/tilda-scripts-3.0.min.js"></script>
+<script src="js/tilda-blocks-page12345.min.js"></script>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RenderScriptURLs(urls, tt.opts))
+		})
+	}
+}
+
+func TestRenderStylesheetURLs(t *testing.T) {
+	urls := []string{
+		"https://static.tildacdn.com/css/tilda-grid-3.0.min.css",
+		"https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.css?t=1734259633",
+	}
+
+	got := RenderStylesheetURLs(urls, TagOptions{Local: true, BasePath: "css/"})
+	assert.Equal(t, template.HTML(`<link rel="stylesheet" href="css/tilda-grid-3.0.min.css">
+<link rel="stylesheet" href="css/tilda-blocks-page12345.min.css">`), got)
+}
+
+func TestIntegrityHash(t *testing.T) {
+	assert.Equal(t, "sha384-OLBgp1GsljhM2TJ+sbHjaiH9txEUvgdDTAzHv2P24donTt6/529l+9Ua0vFImLlb", IntegrityHash(nil))
+	assert.Equal(t, "sha384-HT2E9NfWiuQ/w1PRai+hTyqW16NIoCGA/m8VQDUopfAtcz6YQjtsMmQd5uRbVDpW", IntegrityHash([]byte("alert(1)")))
+}
+
+func TestAssetFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		assetURL string
+		want     string
+	}{
+		{
+			name:     "plain",
+			assetURL: "https://static.tildacdn.com/css/fonts-tildasans.css",
+			want:     "fonts-tildasans.css",
+		}, {
+			name:     "with query",
+			assetURL: "https://static.tildacdn.com/ws/project54321/tilda-blocks-page12345.min.css?t=1734259633",
+			want:     "tilda-blocks-page12345.min.css",
+		}, {
+			name:     "with fragment",
+			assetURL: "/img/logo.svg#icon",
+			want:     "logo.svg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AssetFilename(tt.assetURL))
+		})
+	}
+}